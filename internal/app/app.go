@@ -8,16 +8,23 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"composepack/internal/core/chart"
+	"composepack/internal/core/chartcache"
 	"composepack/internal/core/dockercompose"
+	"composepack/internal/core/images"
+	"composepack/internal/core/oci"
 	"composepack/internal/core/release"
+	"composepack/internal/core/repo"
 	releaseruntime "composepack/internal/core/runtime"
 	"composepack/internal/core/templating"
 	"composepack/internal/core/values"
 	"composepack/internal/infra/config"
+	"composepack/internal/infra/download"
 	"composepack/internal/infra/logging"
 	"composepack/internal/infra/process"
+	"composepack/internal/util/dotenv"
 	"composepack/internal/util/fileloader"
 
 	"sigs.k8s.io/yaml"
@@ -26,6 +33,9 @@ import (
 // ErrNotImplemented is a shared placeholder for unimplemented application flows.
 var ErrNotImplemented = errors.New("not implemented")
 
+// manifestFileName is the name under which the merged compose file is archived per revision.
+const manifestFileName = releaseruntime.ComposeFileName
+
 // Runtime aggregates long-lived dependencies that commands rely on.
 type Runtime struct {
 	Config         config.Config
@@ -35,16 +45,33 @@ type Runtime struct {
 	RuntimeWriter  *releaseruntime.Writer
 	ProcessRunner  *process.Runner
 	DockerRunner   *dockercompose.Runner
+	Images         images.Docker
 	ReleaseStore   *release.Store
+	Downloader     *download.Client
+	ChartCache     *chartcache.Cache
+	Repositories   *repo.Client
+	Registry       *oci.Client
 }
 
 // NewRuntime wires default implementations for the runtime container.
-func NewRuntime(cfg config.Config, logger logging.Logger, loader chart.Loader) *Runtime {
+func NewRuntime(cfg config.Config, logger logging.Logger, loader chart.Loader, downloader *download.Client, cache *chartcache.Cache, repos *repo.Client, registry *oci.Client) *Runtime {
 	if logger == nil {
 		logger = logging.Nop{}
 	}
+	if downloader == nil {
+		downloader = download.New(cfg)
+	}
+	if cache == nil {
+		cache = chartcache.NewCache(cfg, downloader)
+	}
+	if repos == nil {
+		repos = repo.NewClient(cfg, downloader)
+	}
+	if registry == nil {
+		registry = oci.NewClient(downloader)
+	}
 	if loader == nil {
-		loader = NewDefaultChartLoader()
+		loader = NewDefaultChartLoader(cache, cache, repos, registry)
 	}
 	procRunner := process.NewRunner()
 
@@ -56,14 +83,21 @@ func NewRuntime(cfg config.Config, logger logging.Logger, loader chart.Loader) *
 		RuntimeWriter:  &releaseruntime.Writer{},
 		ProcessRunner:  procRunner,
 		DockerRunner:   dockercompose.NewRunner(procRunner),
+		Images:         images.NewCLI(procRunner),
 		ReleaseStore:   &release.Store{},
+		Downloader:     downloader,
+		ChartCache:     cache,
+		Repositories:   repos,
+		Registry:       registry,
 	}
 }
 
-// NewDefaultChartLoader constructs the default filesystem chart loader.
-func NewDefaultChartLoader() chart.Loader {
+// NewDefaultChartLoader constructs the default filesystem chart loader, resolving
+// URLs through getter, `repo/chart` references through repos and `oci://` sources through
+// registry. Pinned remote charts are served from archives when cached.
+func NewDefaultChartLoader(getter chart.Getter, archives chart.ArchiveCache, repos chart.RepositoryResolver, registry chart.OCIFetcher) chart.Loader {
 	fs := chart.NewFileSystemChartLoader(fileloader.NewFileSystemLoader())
-	return chart.NewCompositeLoader(fs)
+	return chart.NewCompositeLoader(fs, getter, archives, repos, registry)
 }
 
 // Application provides methods that implement workflows such as install/up/down.
@@ -80,16 +114,30 @@ func NewApplication(rt *Runtime) *Application {
 type RenderOptions struct {
 	ReleaseName    string
 	ChartSource    string
+	ChartVersion   string // semver range for repository charts; also checked against local charts
+	ChartDigest    string // expected sha256:<hex> of the chart archive (--verify-digest)
+	Verify         bool   // require a valid provenance signature (--verify)
+	Keyring        string // public keys trusted by Verify; defaults to the configured keyring
 	ValueFiles     []string
-	SetValues      map[string]string
+	SetValues      []string // --set key=value[,key=value] (typed)
+	SetStrings     []string // --set-string (always strings)
+	SetJSON        []string // --set-json key=<json>
+	SetFiles       []string // --set-file key=path
+	SetLiterals    []string // --set-literal key=value (verbatim)
+	EnvFiles       []string // dotenv files layered under the process environment
 	RuntimeBaseDir string
 	RuntimePath    string
+	PinDigests     bool // rewrite images to name:tag@digest and record the digests (--pin-digests)
+
+	digestPinned bool // ChartDigest was taken from the release being re-rendered
 }
 
 // InstallOptions drives chart installation into a runtime directory.
 type InstallOptions struct {
 	RenderOptions
-	AutoStart bool
+	AutoStart  bool
+	LoadImages bool // docker load the images bundled in the chart archive
+	NoHooks    bool // skip chart lifecycle hooks
 }
 
 // TemplateOptions render templates without invoking Docker Compose.
@@ -100,7 +148,8 @@ type TemplateOptions struct {
 // UpOptions render and run docker compose up.
 type UpOptions struct {
 	RenderOptions
-	Detach bool
+	Detach  bool
+	NoHooks bool // skip chart lifecycle hooks
 }
 
 // DownOptions control docker compose down behavior.
@@ -136,18 +185,21 @@ type DiffOptions struct {
 
 // InstallRelease implements the install workflow described in the PRD.
 func (a *Application) InstallRelease(ctx context.Context, opts InstallOptions) error {
-	runtimeDir, _, err := a.renderRelease(ctx, opts.RenderOptions)
+	runtimeDir, meta, err := a.renderRelease(ctx, opts.RenderOptions)
 	if err != nil {
 		return err
 	}
+	if opts.LoadImages {
+		// Load from the very archive the release was rendered from.
+		load := chart.LoadOptions{Version: meta.ChartMetadata.Version, Digest: meta.SourceDigest}
+		if _, err := a.LoadBundledImages(ctx, meta.ChartSource, load); err != nil {
+			return err
+		}
+	}
 	if !opts.AutoStart {
-		return nil
+		return a.printNotes(ctx, runtimeDir, meta)
 	}
-	args := []string{"up", "-d"}
-	return a.Runtime.DockerRunner.Run(ctx, dockercompose.CommandOptions{
-		WorkingDir: runtimeDir,
-		Args:       args,
-	})
+	return a.deploy(ctx, runtimeDir, meta, true, !opts.NoHooks)
 }
 
 // TemplateRelease renders templates and writes runtime files without running containers.
@@ -158,18 +210,11 @@ func (a *Application) TemplateRelease(ctx context.Context, opts TemplateOptions)
 
 // UpRelease re-renders templates and invokes docker compose up.
 func (a *Application) UpRelease(ctx context.Context, opts UpOptions) error {
-	runtimeDir, _, err := a.renderRelease(ctx, opts.RenderOptions)
+	runtimeDir, meta, err := a.renderRelease(ctx, opts.RenderOptions)
 	if err != nil {
 		return err
 	}
-	args := []string{"up"}
-	if opts.Detach {
-		args = append(args, "-d")
-	}
-	return a.Runtime.DockerRunner.Run(ctx, dockercompose.CommandOptions{
-		WorkingDir: runtimeDir,
-		Args:       args,
-	})
+	return a.deploy(ctx, runtimeDir, meta, opts.Detach, !opts.NoHooks)
 }
 
 // DownRelease shells out to docker compose down for the given release.
@@ -276,43 +321,62 @@ func (a *Application) DiffRelease(ctx context.Context, opts DiffOptions) error {
 		}
 		// Auto-resolve from existing release metadata
 		chartSource = currentMeta.ChartSource
+		pinToRelease(&opts.RenderOptions, chartSource, currentMeta)
 	}
 
 	// Render the proposed new release in memory (don't write to disk)
-	ch, err := a.Runtime.ChartLoader.Load(ctx, chartSource)
+	ch, err := a.loadChart(ctx, chartSource, opts.RenderOptions)
 	if err != nil {
 		return fmt.Errorf("load chart: %w", err)
 	}
 
-	mergedValues, _, err := a.buildValues(ch, opts.RenderOptions)
+	resolved, err := a.buildValues(ch, opts.RenderOptions)
 	if err != nil {
 		return err
 	}
 
-	rc := templating.RenderContext{
-		Values: mergedValues,
-		Env:    captureEnv(),
-		Release: templating.ReleaseInfo{
-			Name: opts.ReleaseName,
-		},
-		Chart: ch.Metadata,
-		Files: templating.NewFilesAccessor(ch.StaticFiles),
+	var previousEnv map[string]string
+	if currentMeta != nil {
+		previousEnv = currentMeta.Env
 	}
-
-	newComposeFragments, err := a.Runtime.TemplateEngine.RenderComposeFragments(ctx, ch, rc)
+	env, err := a.resolveEnv(ch, resolved.Env, previousEnv)
 	if err != nil {
-		return fmt.Errorf("render new compose templates: %w", err)
+		return err
+	}
+	// Secrets genSecret would generate are shown as a placeholder and not stored.
+	secrets, err := a.Runtime.ReleaseStore.LoadSecrets(currentRuntimeDir)
+	if err != nil {
+		return err
 	}
 
-	newFileAssets, err := a.Runtime.TemplateEngine.RenderFiles(ctx, ch, rc)
+	rc := templating.RenderContext{
+		Values:       resolved.Values,
+		Env:          env.Vars,
+		Release:      releaseInfo(opts.ReleaseName, currentRuntimeDir, currentMeta, time.Now()),
+		Chart:        ch.Metadata,
+		Files:        templating.NewFilesAccessor(ch.StaticFiles),
+		Capabilities: a.capabilities(ctx),
+		Secrets:      previewSecrets{store: secrets},
+		State:        a.releaseState(currentRuntimeDir, currentMeta),
+	}
+
+	newComposeFragments, newFileAssets, err := a.renderChartTree(ctx, ch, rc)
 	if err != nil {
-		return fmt.Errorf("render new file templates: %w", err)
+		return fmt.Errorf("render new release: %w", err)
+	}
+	if _, err := a.renderHooks(ctx, ch, rc, newComposeFragments); err != nil {
+		return err
 	}
 
-	newMergedCompose, _, err := a.mergeFragments(ctx, newComposeFragments, newFileAssets, opts.ReleaseName)
+	newMergedCompose, _, err := a.mergeFragments(ctx, newComposeFragments, newFileAssets, opts.ReleaseName, false)
 	if err != nil {
 		return err
 	}
+	if opts.PinDigests {
+		if newMergedCompose, _, err = a.pinImageDigests(ctx, newComposeFragments, newFileAssets, opts.ReleaseName, newMergedCompose); err != nil {
+			return err
+		}
+	}
 
 	// If no existing release, show what would be created
 	if currentMeta == nil {
@@ -320,7 +384,7 @@ func (a *Application) DiffRelease(ctx context.Context, opts DiffOptions) error {
 	}
 
 	// Load current compose file
-	currentComposePath := filepath.Join(currentRuntimeDir, "docker-compose.yaml")
+	currentComposePath := filepath.Join(currentRuntimeDir, releaseruntime.ComposeFileName)
 	currentCompose, err := os.ReadFile(currentComposePath)
 	if err != nil {
 		return fmt.Errorf("read current compose file: %w", err)
@@ -340,58 +404,101 @@ func (a *Application) renderRelease(ctx context.Context, opts RenderOptions) (st
 	if opts.ReleaseName == "" {
 		return "", nil, errors.New("release name is required")
 	}
+
+	baseDir, currentRuntimeDir, err := a.resolveRuntimeLocation(opts.ReleaseName, opts.RuntimeBaseDir, opts.RuntimePath)
+	if err != nil {
+		return "", nil, err
+	}
+	previous, err := a.Runtime.ReleaseStore.Load(ctx, currentRuntimeDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("load current release metadata: %w", err)
+	}
+
 	if opts.ChartSource == "" {
-		return "", nil, errors.New("chart source must be provided")
+		if previous == nil || previous.ChartSource == "" {
+			return "", nil, errors.New("chart source must be provided")
+		}
+		opts.ChartSource = previous.ChartSource
+		pinToRelease(&opts, opts.ChartSource, previous)
 	}
 
-	ch, err := a.Runtime.ChartLoader.Load(ctx, opts.ChartSource)
+	ch, err := a.loadChart(ctx, opts.ChartSource, opts)
 	if err != nil {
 		return "", nil, fmt.Errorf("load chart: %w", err)
 	}
 
-	mergedValues, valueSources, err := a.buildValues(ch, opts)
+	resolved, err := a.buildValues(ch, opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var previousEnv map[string]string
+	if previous != nil {
+		previousEnv = previous.Env
+	}
+	env, err := a.resolveEnv(ch, resolved.Env, previousEnv)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secretPaths, err := chartSecretPaths(ch)
+	if err != nil {
+		return "", nil, err
+	}
+	secrets, err := a.Runtime.ReleaseStore.LoadSecrets(currentRuntimeDir)
 	if err != nil {
 		return "", nil, err
 	}
 
 	rc := templating.RenderContext{
-		Values: mergedValues,
-		Env:    captureEnv(),
-		Release: templating.ReleaseInfo{
-			Name: opts.ReleaseName,
-		},
-		Chart: ch.Metadata,
-		Files: templating.NewFilesAccessor(ch.StaticFiles),
+		Values:       resolved.Values,
+		Env:          env.Vars,
+		Release:      releaseInfo(opts.ReleaseName, currentRuntimeDir, previous, time.Now()),
+		Chart:        ch.Metadata,
+		Files:        templating.NewFilesAccessor(ch.StaticFiles),
+		Capabilities: a.capabilities(ctx),
+		Secrets:      secrets,
+		State:        a.releaseState(currentRuntimeDir, previous),
 	}
 
-	composeFragments, err := a.Runtime.TemplateEngine.RenderComposeFragments(ctx, ch, rc)
+	composeFragments, fileAssets, err := a.renderChartTree(ctx, ch, rc)
 	if err != nil {
-		return "", nil, fmt.Errorf("render compose templates: %w", err)
+		return "", nil, err
 	}
-	if len(composeFragments) == 0 {
+	if len(composeFragments.names) == 0 {
 		return "", nil, errors.New("chart produced no compose templates")
 	}
-
-	fileAssets, err := a.Runtime.TemplateEngine.RenderFiles(ctx, ch, rc)
+	hookSet, err := a.renderHooks(ctx, ch, rc, composeFragments)
 	if err != nil {
-		return "", nil, fmt.Errorf("render file templates: %w", err)
+		return "", nil, err
 	}
-
-	mergedCompose, orderedFragments, err := a.mergeFragments(ctx, composeFragments, fileAssets, opts.ReleaseName)
+	notes, err := a.renderNotesTree(ctx, ch, rc)
 	if err != nil {
 		return "", nil, err
 	}
 
-	baseDir, _, err := a.resolveRuntimeLocation(opts.ReleaseName, opts.RuntimeBaseDir, opts.RuntimePath)
+	// Persist generated secrets before anything renders them to disk, so they are never lost.
+	if err := secrets.Save(ctx); err != nil {
+		return "", nil, err
+	}
+
+	mergedCompose, orderedFragments, err := a.mergeFragments(ctx, composeFragments, fileAssets, opts.ReleaseName, false)
 	if err != nil {
 		return "", nil, err
 	}
+	var pinnedImages []release.ImageDigest
+	if opts.PinDigests {
+		if mergedCompose, pinnedImages, err = a.pinImageDigests(ctx, composeFragments, fileAssets, opts.ReleaseName, mergedCompose); err != nil {
+			return "", nil, err
+		}
+	}
 
 	runtimeDir, err := a.Runtime.RuntimeWriter.Write(ctx, releaseruntime.WriteOptions{
 		ReleaseName: opts.ReleaseName,
 		BaseDir:     baseDir,
 		ComposeYAML: mergedCompose,
 		Files:       fileAssets,
+		HookScripts: hookSet.Scripts,
 	})
 	if err != nil {
 		return "", nil, fmt.Errorf("write runtime directory: %w", err)
@@ -399,20 +506,78 @@ func (a *Application) renderRelease(ctx context.Context, opts RenderOptions) (st
 
 	meta := &release.Metadata{
 		ReleaseName:   opts.ReleaseName,
+		Revision:      rc.Release.Revision,
+		CreatedAt:     rc.Release.Time,
 		ChartMetadata: ch.Metadata,
 		ChartSource:   opts.ChartSource,
-		Values:        deepCopyMap(mergedValues),
-		ValuesSources: valueSources,
+		SourceDigest:  ch.Digest,
+		Values:        deepCopyMap(resolved.Values),
+		UserValues:    deepCopyMap(resolved.UserValues),
+		SecretPaths:   secretPaths,
+		ValuesSources: resolved.Sources,
+		Env:           env.Snapshot,
 		ComposeFiles:  orderedFragments,
+		Images:        pinnedImages,
+		Hooks:         hookSet.Hooks,
+		Deployed:      previous != nil && previous.Deployed,
 	}
 
 	if err := a.Runtime.ReleaseStore.Save(ctx, runtimeDir, meta); err != nil {
 		return "", nil, fmt.Errorf("save release metadata: %w", err)
 	}
+	if err := a.Runtime.ReleaseStore.SaveArtifact(ctx, runtimeDir, meta.Revision, manifestFileName, mergedCompose); err != nil {
+		return "", nil, fmt.Errorf("save release manifest: %w", err)
+	}
+	if notes != nil {
+		if err := a.Runtime.ReleaseStore.SaveArtifact(ctx, runtimeDir, meta.Revision, notesFileName, notes); err != nil {
+			return "", nil, fmt.Errorf("save release notes: %w", err)
+		}
+	}
 
 	return runtimeDir, meta, nil
 }
 
+// loadChart loads a chart, honouring a version range and archive digest when the loader supports them.
+func (a *Application) loadChart(ctx context.Context, source string, opts RenderOptions) (*chart.Chart, error) {
+	if opts.ChartVersion == "" && opts.ChartDigest == "" && !opts.Verify {
+		return a.Runtime.ChartLoader.Load(ctx, source)
+	}
+	loader, ok := a.Runtime.ChartLoader.(chart.VersionedLoader)
+	if !ok {
+		return nil, fmt.Errorf("chart loader does not support --version, --verify-digest or --verify")
+	}
+	loadOpts := chart.LoadOptions{Version: opts.ChartVersion, Digest: opts.ChartDigest}
+	if opts.Verify {
+		keyring, err := a.loadKeyring(opts.Keyring)
+		if err != nil {
+			return nil, err
+		}
+		loadOpts.Verifier = keyring
+	}
+	ch, err := loader.LoadWith(ctx, source, loadOpts)
+	if err != nil && opts.digestPinned && errors.Is(err, chart.ErrDigestMismatch) {
+		return nil, fmt.Errorf("%w; the release is pinned to the archive it was rendered from, pass --chart to re-resolve it", err)
+	}
+	return ch, err
+}
+
+// pinToRelease makes a re-render from the release's recorded remote source use the same
+// chart: repository and OCI charts keep their version, and the exact archive recorded in
+// release.json is reused (from the chart cache when available). An explicit --version opts out.
+func pinToRelease(opts *RenderOptions, source string, previous *release.Metadata) {
+	if previous == nil || opts.ChartVersion != "" || !(chart.IsRemote(source) || chart.IsRepositoryRef(source)) {
+		return
+	}
+	if !chart.IsURL(source) {
+		// Re-rendering an existing release must not silently upgrade a remote chart.
+		opts.ChartVersion = previous.ChartMetadata.Version
+	}
+	if opts.ChartDigest == "" && previous.SourceDigest != "" {
+		opts.ChartDigest = previous.SourceDigest
+		opts.digestPinned = true
+	}
+}
+
 func (a *Application) resolveBaseDir(override string) (string, error) {
 	if override != "" {
 		return override, nil
@@ -448,75 +613,171 @@ func (a *Application) resolveRuntimeLocation(release, baseOverride, runtimePath
 	return base, filepath.Join(base, release), nil
 }
 
-func (a *Application) buildValues(ch *chart.Chart, opts RenderOptions) (map[string]any, []string, error) {
-	var result map[string]any
-	if ch.Values != nil {
-		copied := deepCopyMap(ch.Values)
-		result = copied
-	} else {
-		result = map[string]any{}
-	}
+// resolvedValues is the outcome of layering chart defaults with user overrides.
+type resolvedValues struct {
+	Values     map[string]any // effective values exposed as .Values
+	UserValues map[string]any // user-supplied overrides only (-f files and --set)
+	Sources    []string
+	Trace      *values.Trace     // which layer set each leaf of Values
+	Env        map[string]string // environment exposed as .Env (env files + process env)
+}
 
-	sources := []string{"chart:values.yaml"}
+const chartValuesSource = "chart:values.yaml"
+
+func (a *Application) buildValues(ch *chart.Chart, opts RenderOptions) (*resolvedValues, error) {
+	layers := chartDefaultLayers(ch)
+	defaults := len(layers)
 
 	for _, path := range opts.ValueFiles {
 		contents, err := loadValuesFile(path)
 		if err != nil {
-			return nil, nil, fmt.Errorf("load values file %s: %w", path, err)
+			return nil, fmt.Errorf("load values file %s: %w", path, err)
 		}
-		result, err = values.Merge(result, contents)
+		layers = append(layers, values.Layer{Source: path, Values: contents})
+	}
+
+	env, envLayers, err := buildEnvLayers(ch, opts.EnvFiles)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, envLayers...)
+
+	overrides, err := buildSetOverrides(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range overrides {
+		layers = append(layers, values.Layer{Source: "cli:" + strings.TrimPrefix(layer.flag, "--"), Values: layer.values})
+	}
+
+	strategies, err := chartMergeStrategies(ch)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := values.NewTrace()
+	merger := &values.Merger{Strategies: strategies, Trace: trace}
+	result, err := merger.Merge(layers...)
+	if err != nil {
+		return nil, fmt.Errorf("merge values: %w", err)
+	}
+	user, err := (&values.Merger{Strategies: strategies}).Merge(layers[defaults:]...)
+	if err != nil {
+		return nil, fmt.Errorf("merge values: %w", err)
+	}
+
+	if err := values.Validate(ch.ValuesSchema, result); err != nil {
+		return nil, fmt.Errorf("validate values: %w", err)
+	}
+	if err := validateSubcharts(ch, result); err != nil {
+		return nil, err
+	}
+
+	sources := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		sources = append(sources, layer.Source)
+	}
+
+	return &resolvedValues{Values: result, UserValues: user, Sources: sources, Trace: trace, Env: env}, nil
+}
+
+// buildEnvLayers loads --env-file files and the process environment, returning the combined
+// environment (process variables win) and one values layer per source that maps onto values
+// via COMPOSEPACK_VALUE_* variables or x-env schema annotations.
+func buildEnvLayers(ch *chart.Chart, envFiles []string) (map[string]string, []values.Layer, error) {
+	env := map[string]string{}
+	var layers []values.Layer
+
+	addSource := func(source string, vars map[string]string) error {
+		overlay, err := values.EnvOverlay(vars, ch.ValuesSchema)
 		if err != nil {
-			return nil, nil, fmt.Errorf("merge values file %s: %w", path, err)
+			return fmt.Errorf("apply %s: %w", source, err)
 		}
-		sources = append(sources, path)
+		if len(overlay) > 0 {
+			layers = append(layers, values.Layer{Source: source, Values: overlay})
+		}
+		for k, v := range vars {
+			env[k] = v
+		}
+		return nil
 	}
 
-	if len(opts.SetValues) > 0 {
-		setOverrides := buildSetOverrides(opts.SetValues)
-		if len(setOverrides) > 0 {
-			var err error
-			result, err = values.Merge(result, setOverrides)
-			if err != nil {
-				return nil, nil, fmt.Errorf("apply --set overrides: %w", err)
-			}
-			sources = append(sources, "cli:set")
+	for _, path := range envFiles {
+		vars, err := dotenv.Load(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load env file %s: %w", path, err)
+		}
+		if err := addSource("env-file:"+path, vars); err != nil {
+			return nil, nil, err
 		}
 	}
-
-	if err := values.Validate(ch.ValuesSchema, result); err != nil {
-		return nil, nil, fmt.Errorf("validate values: %w", err)
+	if err := addSource("env", captureEnv()); err != nil {
+		return nil, nil, err
 	}
 
-	return result, sources, nil
+	return env, layers, nil
 }
 
-func (a *Application) mergeFragments(ctx context.Context, fragments map[string][]byte, files map[string][]byte, releaseName string) ([]byte, []string, error) {
-	tempDir, err := os.MkdirTemp("", "composepack-fragments-*")
-	if err != nil {
-		return nil, nil, fmt.Errorf("create temp directory: %w", err)
+// fragmentLayers are rendered compose files in the order docker compose layers them: each
+// fragment overrides the ones before it.
+type fragmentLayers struct {
+	names []string
+	data  map[string][]byte
+}
+
+func newFragmentLayers() *fragmentLayers {
+	return &fragmentLayers{data: map[string][]byte{}}
+}
+
+// add layers a fragment over the ones added before it.
+func (f *fragmentLayers) add(name string, data []byte) error {
+	if _, exists := f.data[name]; exists {
+		return fmt.Errorf("compose fragment %s is rendered twice", name)
 	}
-	defer os.RemoveAll(tempDir)
+	f.names = append(f.names, name)
+	f.data[name] = data
+	return nil
+}
 
+// addSorted layers fragments in name order, the order of a chart's own templates.
+func (f *fragmentLayers) addSorted(fragments map[string][]byte) error {
 	names := make([]string, 0, len(fragments))
 	for name := range fragments {
 		names = append(names, name)
 	}
 	sort.Strings(names)
+	for _, name := range names {
+		if err := f.add(name, fragments[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeFragments merges compose fragments, in order, with `docker compose config`.
+// resolveDigests makes compose pin every image to its registry digest.
+func (a *Application) mergeFragments(ctx context.Context, fragments *fragmentLayers, files map[string][]byte, releaseName string, resolveDigests bool) ([]byte, []string, error) {
+	tempDir, err := os.MkdirTemp("", "composepack-fragments-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
 
+	names := append([]string{}, fragments.names...)
 	var fragmentPaths []string
 	for _, name := range names {
 		dest := filepath.Join(tempDir, name)
 		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
 			return nil, nil, fmt.Errorf("prepare fragment directory: %w", err)
 		}
-		if err := os.WriteFile(dest, fragments[name], 0o644); err != nil {
+		if err := os.WriteFile(dest, fragments.data[name], 0o644); err != nil {
 			return nil, nil, fmt.Errorf("write fragment %s: %w", name, err)
 		}
 		fragmentPaths = append(fragmentPaths, dest)
 	}
 
 	if len(files) > 0 {
-		filesRoot := filepath.Join(tempDir, "files")
+		filesRoot := filepath.Join(tempDir, releaseruntime.FilesDir)
 		for path, data := range files {
 			target := filepath.Join(filesRoot, path)
 			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
@@ -529,9 +790,10 @@ func (a *Application) mergeFragments(ctx context.Context, fragments map[string][
 	}
 
 	data, err := a.Runtime.DockerRunner.MergeFragments(ctx, dockercompose.MergeOptions{
-		WorkingDir:    tempDir,
-		FragmentPaths: fragmentPaths,
-		ProjectName:   releaseName,
+		WorkingDir:          tempDir,
+		FragmentPaths:       fragmentPaths,
+		ProjectName:         releaseName,
+		ResolveImageDigests: resolveDigests,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -557,30 +819,46 @@ func loadValuesFile(path string) (map[string]any, error) {
 	return out, nil
 }
 
-func buildSetOverrides(in map[string]string) map[string]any {
-	out := make(map[string]any, len(in))
-	for key, val := range in {
-		assignSetValue(out, strings.Split(key, "."), val)
-	}
-	return out
+// setLayer is the parsed result of one family of --set flags.
+type setLayer struct {
+	flag   string
+	values map[string]any
 }
 
-func assignSetValue(dst map[string]any, path []string, value string) {
-	if len(path) == 0 {
-		return
-	}
-	key := path[0]
-	if len(path) == 1 {
-		dst[key] = value
-		return
-	}
-
-	next, ok := dst[key].(map[string]any)
-	if !ok {
-		next = map[string]any{}
-		dst[key] = next
+// buildSetOverrides parses the --set family of flags in Helm's precedence order:
+// --set-json, --set, --set-string, --set-file, --set-literal.
+func buildSetOverrides(opts RenderOptions) ([]setLayer, error) {
+	families := []struct {
+		flag  string
+		exprs []string
+		parse func(string, map[string]any) error
+	}{
+		{"--set-json", opts.SetJSON, values.ParseSetJSON},
+		{"--set", opts.SetValues, values.ParseSet},
+		{"--set-string", opts.SetStrings, values.ParseSetString},
+		{"--set-file", opts.SetFiles, values.ParseSetFile},
+		{"--set-literal", opts.SetLiterals, values.ParseSetLiteral},
+	}
+
+	var layers []setLayer
+	for _, family := range families {
+		if len(family.exprs) == 0 {
+			continue
+		}
+		parsed := map[string]any{}
+		for _, expr := range family.exprs {
+			if expr == "" {
+				continue
+			}
+			if err := family.parse(expr, parsed); err != nil {
+				return nil, fmt.Errorf("invalid %s value %q: %w", family.flag, expr, err)
+			}
+		}
+		if len(parsed) > 0 {
+			layers = append(layers, setLayer{flag: family.flag, values: parsed})
+		}
 	}
-	assignSetValue(next, path[1:], value)
+	return layers, nil
 }
 
 func deepCopyMap(src map[string]any) map[string]any {
@@ -609,24 +887,8 @@ func deepCopyValue(val any) any {
 	}
 }
 
-func captureEnv() map[string]string {
-	env := os.Environ()
-	out := make(map[string]string, len(env))
-	for _, kv := range env {
-		if kv == "" {
-			continue
-		}
-		parts := strings.SplitN(kv, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		out[parts[0]] = parts[1]
-	}
-	return out
-}
-
 func (a *Application) loadCurrentFiles(runtimeDir string) (map[string][]byte, error) {
-	filesDir := filepath.Join(runtimeDir, "files")
+	filesDir := filepath.Join(runtimeDir, releaseruntime.FilesDir)
 	files := make(map[string][]byte)
 
 	// Check if files directory exists
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"composepack/internal/core/release"
+	"composepack/internal/core/templating"
+	"composepack/internal/version"
+)
+
+// releaseInfo builds `.Release` for rendering a new revision on top of previous (nil when
+// the release does not exist yet).
+func releaseInfo(name, runtimeDir string, previous *release.Metadata, now time.Time) templating.ReleaseInfo {
+	info := templating.ReleaseInfo{
+		Name:       name,
+		Revision:   1,
+		IsInstall:  previous == nil,
+		IsUpgrade:  previous != nil,
+		RuntimeDir: runtimeDir,
+		Time:       now.UTC(),
+	}
+	if previous != nil {
+		info.Revision = previous.Revision + 1
+		info.PreviousChartVersion = previous.ChartMetadata.Version
+	}
+	return info
+}
+
+// capabilities detects the docker tooling for `.Capabilities`. Detection is best effort:
+// whatever cannot be queried (no Docker, daemon down) is left empty so charts still render
+// offline, e.g. while linting or packaging.
+func (a *Application) capabilities(ctx context.Context) templating.Capabilities {
+	caps := templating.Capabilities{ComposePackVersion: version.Version}
+	if v, err := a.Runtime.DockerRunner.Version(ctx); err == nil {
+		caps.ComposeVersion = v
+	} else {
+		a.Runtime.Logger.Debug("detect docker compose version: %v", err)
+	}
+	if engine, err := a.Runtime.DockerRunner.EngineInfo(ctx); err == nil {
+		caps.Docker = templating.DockerInfo{Version: engine.Version, OS: engine.OS, Arch: engine.Arch}
+	} else {
+		a.Runtime.Logger.Debug("detect docker engine: %v", err)
+	}
+	return caps
+}
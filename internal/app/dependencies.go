@@ -0,0 +1,350 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/core/hooks"
+	"composepack/internal/core/templating"
+	"composepack/internal/core/values"
+)
+
+// globalValuesKey holds values shared between a chart and all of its subcharts.
+const globalValuesKey = "global"
+
+// chartDefaultLayers returns the default value layers for a chart tree: every subchart's
+// values.yaml nested under its alias (deepest first), then the chart's own values.yaml.
+// Subchart `global:` defaults are lifted to the top-level `global` key.
+func chartDefaultLayers(ch *chart.Chart) []values.Layer {
+	var layers []values.Layer
+	collectDefaultLayers(ch, nil, &layers)
+	return layers
+}
+
+func collectDefaultLayers(ch *chart.Chart, prefix []string, layers *[]values.Layer) {
+	for _, sub := range ch.Dependencies {
+		collectDefaultLayers(sub.Chart, append(append([]string{}, prefix...), sub.Dependency.Key()), layers)
+	}
+	source := chartValuesSource
+	if len(prefix) > 0 {
+		source = fmt.Sprintf("chart:%s/%s", strings.Join(prefix, "/"), chart.ValuesFile)
+	}
+	*layers = append(*layers, values.Layer{Source: source, Values: nestValues(prefix, ch.Values)})
+}
+
+// nestValues places vals below prefix, keeping the `global` key at the top level.
+func nestValues(prefix []string, vals map[string]any) map[string]any {
+	if len(prefix) == 0 || vals == nil {
+		return vals
+	}
+	scoped := make(map[string]any, len(vals))
+	out := map[string]any{}
+	for k, v := range vals {
+		if k == globalValuesKey {
+			out[globalValuesKey] = v
+			continue
+		}
+		scoped[k] = v
+	}
+
+	node := out
+	for i, seg := range prefix {
+		if i == len(prefix)-1 {
+			node[seg] = scoped
+			break
+		}
+		next := map[string]any{}
+		node[seg] = next
+		node = next
+	}
+	return out
+}
+
+// subchartValues scopes parent values to a subchart: `.Values.<alias>` plus the shared `global` map.
+func subchartValues(parent map[string]any, dep chart.Dependency) map[string]any {
+	scoped, _ := parent[dep.Key()].(map[string]any)
+	scoped = deepCopyMap(scoped)
+	if scoped == nil {
+		scoped = map[string]any{}
+	}
+	if global, ok := parent[globalValuesKey]; ok {
+		scoped[globalValuesKey] = deepCopyValue(global)
+	}
+	return scoped
+}
+
+// dependencyEnabled evaluates a dependency condition against the parent values. The first
+// condition path that resolves to a boolean decides; missing paths leave the subchart enabled.
+func dependencyEnabled(dep chart.Dependency, parent map[string]any) (bool, error) {
+	if strings.TrimSpace(dep.Condition) == "" {
+		return true, nil
+	}
+	for _, cond := range strings.Split(dep.Condition, ",") {
+		cond = strings.TrimSpace(cond)
+		if cond == "" {
+			continue
+		}
+		val, ok := lookupPath(parent, values.SplitPath(cond))
+		if !ok || val == nil {
+			continue
+		}
+		enabled, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("dependency %s: condition %s must be a boolean, got %T", dep.Key(), cond, val)
+		}
+		return enabled, nil
+	}
+	return true, nil
+}
+
+func lookupPath(node map[string]any, path []string) (any, bool) {
+	var current any = node
+	for _, seg := range path {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[seg]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// validateSubcharts validates each enabled subchart's scoped values against its own schema.
+func validateSubcharts(ch *chart.Chart, vals map[string]any) error {
+	for _, sub := range ch.Dependencies {
+		enabled, err := dependencyEnabled(sub.Dependency, vals)
+		if err != nil {
+			return err
+		}
+		if !enabled {
+			continue
+		}
+		scoped := subchartValues(vals, sub.Dependency)
+		if err := values.Validate(sub.Chart.ValuesSchema, scoped); err != nil {
+			return fmt.Errorf("validate values for dependency %s: %w", sub.Dependency.Key(), err)
+		}
+		if err := validateSubcharts(sub.Chart, scoped); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chartSecretPaths collects x-secret annotations across the chart tree, prefixing subchart paths with their alias.
+func chartSecretPaths(ch *chart.Chart) (map[string]values.SecretMode, error) {
+	out := map[string]values.SecretMode{}
+	err := walkChartSchemas(ch, nil, func(prefix string, schema []byte) error {
+		paths, err := values.SecretPaths(schema)
+		if err != nil {
+			return err
+		}
+		for p, mode := range paths {
+			out[prefix+p] = mode
+		}
+		return nil
+	})
+	return out, err
+}
+
+// chartMergeStrategies collects x-merge annotations across the chart tree, prefixing subchart paths with their alias.
+func chartMergeStrategies(ch *chart.Chart) (map[string]values.ListStrategy, error) {
+	out := map[string]values.ListStrategy{}
+	err := walkChartSchemas(ch, nil, func(prefix string, schema []byte) error {
+		strategies, err := values.MergeStrategies(schema)
+		if err != nil {
+			return err
+		}
+		for p, strategy := range strategies {
+			out[prefix+p] = strategy
+		}
+		return nil
+	})
+	return out, err
+}
+
+func walkChartSchemas(ch *chart.Chart, prefix []string, visit func(prefix string, schema []byte) error) error {
+	joined := ""
+	if len(prefix) > 0 {
+		joined = values.JoinPath(prefix) + "."
+	}
+	if err := visit(joined, ch.ValuesSchema); err != nil {
+		return fmt.Errorf("chart %s: %w", ch.Metadata.Name, err)
+	}
+	for _, sub := range ch.Dependencies {
+		if err := walkChartSchemas(sub.Chart, append(append([]string{}, prefix...), sub.Dependency.Key()), visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chartEnvDecls collects env declarations across the chart tree; the first declaration of a name wins.
+func chartEnvDecls(ch *chart.Chart) []chart.EnvVar {
+	seen := map[string]bool{}
+	var out []chart.EnvVar
+	var walk func(*chart.Chart)
+	walk = func(c *chart.Chart) {
+		for _, decl := range c.Metadata.Env {
+			if seen[decl.Name] {
+				continue
+			}
+			seen[decl.Name] = true
+			out = append(out, decl)
+		}
+		for _, sub := range c.Dependencies {
+			walk(sub.Chart)
+		}
+	}
+	walk(ch)
+	return out
+}
+
+// renderChartTree renders a chart and its enabled subcharts. Subchart compose fragments and
+// genSecret names are namespaced under charts/<alias>/. Subchart fragments are layered first,
+// in declaration order, so the parent's own fragments override its dependencies. Rendered
+// files share the release files/ tree and must not collide.
+func (a *Application) renderChartTree(ctx context.Context, ch *chart.Chart, rc templating.RenderContext) (*fragmentLayers, map[string][]byte, error) {
+	own, err := a.Runtime.TemplateEngine.RenderComposeFragments(ctx, ch, rc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("render compose templates: %w", err)
+	}
+	files, err := a.Runtime.TemplateEngine.RenderFiles(ctx, ch, rc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("render file templates: %w", err)
+	}
+
+	fragments := newFragmentLayers()
+	for _, sub := range ch.Dependencies {
+		subRC, enabled, err := dependencyContext(rc, sub)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !enabled {
+			continue
+		}
+
+		subFragments, subFiles, err := a.renderChartTree(ctx, sub.Chart, subRC)
+		if err != nil {
+			return nil, nil, fmt.Errorf("dependency %s: %w", sub.Dependency.Key(), err)
+		}
+		for _, name := range subFragments.names {
+			if err := fragments.add(path.Join(chart.ChartsDir, sub.Dependency.Key(), name), subFragments.data[name]); err != nil {
+				return nil, nil, err
+			}
+		}
+		for name, data := range subFiles {
+			if _, exists := files[name]; exists {
+				return nil, nil, fmt.Errorf("dependency %s: file %s collides with a file rendered by its parent chart", sub.Dependency.Key(), name)
+			}
+			files[name] = data
+		}
+	}
+
+	if err := fragments.addSorted(own); err != nil {
+		return nil, nil, err
+	}
+	return fragments, files, nil
+}
+
+// dependencyContext derives the render context of a subchart from its parent's, reporting
+// whether the dependency is enabled.
+func dependencyContext(rc templating.RenderContext, sub *chart.Subchart) (templating.RenderContext, bool, error) {
+	enabled, err := dependencyEnabled(sub.Dependency, rc.Values)
+	if err != nil || !enabled {
+		return rc, false, err
+	}
+	subRC := rc
+	subRC.Values = subchartValues(rc.Values, sub.Dependency)
+	subRC.Chart = sub.Chart.Metadata
+	subRC.Files = templating.NewFilesAccessor(sub.Chart.StaticFiles)
+	if rc.Secrets != nil {
+		subRC.Secrets = scopedSecrets{prefix: path.Join(chart.ChartsDir, sub.Dependency.Key()) + "/", store: rc.Secrets}
+	}
+	return subRC, true, nil
+}
+
+// renderHookTree renders the hooks of a chart and its enabled subcharts. A dependency's
+// scripts and hook fragments are namespaced under charts/<alias>/; its hook services keep
+// their names, like its other services.
+func (a *Application) renderHookTree(ctx context.Context, ch *chart.Chart, rc templating.RenderContext) (*hooks.Set, error) {
+	rendered, err := a.Runtime.TemplateEngine.RenderHooks(ctx, ch, rc)
+	if err != nil {
+		return nil, fmt.Errorf("render hook templates: %w", err)
+	}
+	set, err := hooks.Parse(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%w", chart.TemplatesHooks, err) // err starts with the file name
+	}
+
+	for _, sub := range ch.Dependencies {
+		subRC, enabled, err := dependencyContext(rc, sub)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			continue
+		}
+		subSet, err := a.renderHookTree(ctx, sub.Chart, subRC)
+		if err == nil {
+			err = set.Add(path.Join(chart.ChartsDir, sub.Dependency.Key()), subSet)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", sub.Dependency.Key(), err)
+		}
+	}
+	return set, nil
+}
+
+// renderNotesTree renders the NOTES.txt of a chart followed by those of its enabled
+// subcharts, in declaration order. It returns nil when no chart has notes.
+func (a *Application) renderNotesTree(ctx context.Context, ch *chart.Chart, rc templating.RenderContext) ([]byte, error) {
+	notes, err := a.Runtime.TemplateEngine.RenderNotes(ctx, ch, rc)
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", chart.NotesFile, err)
+	}
+
+	for _, sub := range ch.Dependencies {
+		subRC, enabled, err := dependencyContext(rc, sub)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			continue
+		}
+		subNotes, err := a.renderNotesTree(ctx, sub.Chart, subRC)
+		if err != nil {
+			return nil, fmt.Errorf("dependency %s: %w", sub.Dependency.Key(), err)
+		}
+		if len(bytes.TrimSpace(subNotes)) == 0 {
+			continue
+		}
+		if len(notes) > 0 {
+			notes = append(bytes.TrimRight(notes, "\n"), '\n', '\n')
+		}
+		notes = append(notes, subNotes...)
+	}
+	return notes, nil
+}
+
+// scopedSecrets gives a subchart its own genSecret names, so two aliases of one chart (or a
+// parent using the same name) do not share generated values.
+type scopedSecrets struct {
+	prefix string // charts/<alias>/
+	store  templating.SecretGenerator
+}
+
+// Secret implements templating.SecretGenerator.
+func (s scopedSecrets) Secret(name string, length int) (string, error) {
+	if name == "" {
+		return "", errors.New("secret name is required")
+	}
+	return s.store.Secret(s.prefix+name, length)
+}
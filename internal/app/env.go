@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"composepack/internal/core/chart"
+)
+
+// chartEnv is the environment exposed to templates for one render.
+type chartEnv struct {
+	Vars     map[string]string // exposed as .Env
+	Snapshot map[string]string // non-secret declared values recorded in release.json
+}
+
+// resolveChartEnv narrows the environment to the variables declared in Chart.yaml.
+// Each declared variable resolves from the current environment, then the snapshot recorded
+// by the previous revision, then its default. Missing required variables fail the render.
+// Charts that declare no variables get an empty environment: what they would see could not
+// be reproduced from release.json.
+func resolveChartEnv(decls []chart.EnvVar, env map[string]string, previous map[string]string) (*chartEnv, error) {
+	out := &chartEnv{Vars: map[string]string{}, Snapshot: map[string]string{}}
+	var missing []string
+	for _, decl := range decls {
+		val, ok := env[decl.Name]
+		if !ok && !decl.Secret {
+			val, ok = previous[decl.Name]
+		}
+		if !ok && decl.Default != nil {
+			val, ok = *decl.Default, true
+		}
+		if !ok {
+			if decl.Required {
+				missing = append(missing, describeEnvVar(decl))
+			}
+			continue
+		}
+		out.Vars[decl.Name] = val
+		if !decl.Secret {
+			out.Snapshot[decl.Name] = val
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("missing required environment variables: %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// envReference matches the template constructs that read the environment.
+var envReference = regexp.MustCompile(`\.Env\b|\b(env|expandenv)\s+["$(.]`)
+
+// undeclaredEnvUse reports whether a chart without env declarations has templates reading
+// the environment, which used to see the whole process environment.
+func undeclaredEnvUse(ch *chart.Chart, decls []chart.EnvVar) bool {
+	if len(decls) > 0 {
+		return false
+	}
+	var reads func(*chart.Chart) bool
+	reads = func(c *chart.Chart) bool {
+		if envReference.MatchString(c.NotesTpl) {
+			return true
+		}
+		for _, tpls := range []map[string]string{c.ComposeTpls, c.FileTemplates, c.HelperTpls, c.HookTpls} {
+			for _, content := range tpls {
+				if envReference.MatchString(content) {
+					return true
+				}
+			}
+		}
+		for _, sub := range c.Dependencies {
+			if reads(sub.Chart) {
+				return true
+			}
+		}
+		return false
+	}
+	return reads(ch)
+}
+
+// undeclaredEnvWarning explains why .Env is empty for charts flagged by undeclaredEnvUse.
+const undeclaredEnvWarning = "templates read .Env but Chart.yaml declares no env variables; .Env is empty (charts no longer see the whole environment), declare the variables under env"
+
+// resolveEnv resolves the chart environment, warning about charts that relied on seeing the
+// whole process environment.
+func (a *Application) resolveEnv(ch *chart.Chart, env map[string]string, previous map[string]string) (*chartEnv, error) {
+	decls := chartEnvDecls(ch)
+	if undeclaredEnvUse(ch, decls) {
+		a.Runtime.Logger.Warn("chart %s: %s", ch.Metadata.Name, undeclaredEnvWarning)
+	}
+	return resolveChartEnv(decls, env, previous)
+}
+
+func describeEnvVar(decl chart.EnvVar) string {
+	if decl.Description == "" {
+		return decl.Name
+	}
+	return fmt.Sprintf("%s (%s)", decl.Name, decl.Description)
+}
+
+func captureEnv() map[string]string {
+	env := os.Environ()
+	out := make(map[string]string, len(env))
+	for _, kv := range env {
+		if kv == "" {
+			continue
+		}
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		out[parts[0]] = parts[1]
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"composepack/internal/core/values"
+)
+
+// maskedValue replaces secret values in explanations unless ExplainOptions.Reveal is set.
+const maskedValue = "<secret>"
+
+// ExplainOptions select the release, chart and value layers to explain.
+type ExplainOptions struct {
+	RenderOptions
+	Reveal bool // show values marked x-secret instead of masking them
+}
+
+// ValueProvenance describes where one leaf of the merged values came from.
+type ValueProvenance struct {
+	Path       string              `json:"path"`
+	Value      any                 `json:"value,omitempty"`
+	Source     string              `json:"source"`
+	Deleted    bool                `json:"deleted,omitempty"`
+	Overridden []values.Assignment `json:"overridden,omitempty"`
+}
+
+// ValuesExplanation is the merged values tree annotated with per-leaf provenance.
+type ValuesExplanation struct {
+	ReleaseName string            `json:"releaseName"`
+	ChartSource string            `json:"chartSource"`
+	Sources     []string          `json:"sources"`
+	Values      map[string]any    `json:"values"`
+	Leaves      []ValueProvenance `json:"leaves"`
+	Deleted     []ValueProvenance `json:"deleted,omitempty"`
+}
+
+// ExplainValues resolves values exactly like install/up would and reports, for every leaf,
+// the winning source and the sources it overrode.
+func (a *Application) ExplainValues(ctx context.Context, opts ExplainOptions) (*ValuesExplanation, error) {
+	chartSource, err := a.resolveChartSource(ctx, &opts.RenderOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := a.loadChart(ctx, chartSource, opts.RenderOptions)
+	if err != nil {
+		return nil, fmt.Errorf("load chart: %w", err)
+	}
+
+	resolved, err := a.buildValues(ch, opts.RenderOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	shown := resolved.Values
+	masked := map[string]bool{}
+	var secretPaths map[string]values.SecretMode
+	if !opts.Reveal {
+		secretPaths, err = chartSecretPaths(ch)
+		if err != nil {
+			return nil, err
+		}
+		shown, err = values.TransformPaths(resolved.Values, secretPaths, func(path string, _ values.SecretMode, _ any) (any, error) {
+			masked[path] = true
+			return maskedValue, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := &ValuesExplanation{
+		ReleaseName: opts.ReleaseName,
+		ChartSource: chartSource,
+		Sources:     resolved.Sources,
+		Values:      shown,
+	}
+
+	present := map[string]bool{}
+	walkLeaves(shown, nil, func(path []string, val any) {
+		key := values.JoinPath(path)
+		present[key] = true
+		entry := ValueProvenance{Path: key, Value: val}
+		history := resolved.Trace.History(key)
+		if len(history) > 0 {
+			entry.Source = history[len(history)-1].Source
+			entry.Overridden = append([]values.Assignment{}, history[:len(history)-1]...)
+		}
+		if masked[key] {
+			for i := range entry.Overridden {
+				if !entry.Overridden[i].Deleted {
+					entry.Overridden[i].Value = maskedValue
+				}
+			}
+		}
+		out.Leaves = append(out.Leaves, entry)
+	})
+
+	for _, path := range resolved.Trace.Paths() {
+		winner, ok := resolved.Trace.Winner(path)
+		if !ok || !winner.Deleted || presentAt(present, path) {
+			continue
+		}
+		history := resolved.Trace.History(path)
+		overridden, err := maskAssignments(path, history[:len(history)-1], secretPaths)
+		if err != nil {
+			return nil, err
+		}
+		out.Deleted = append(out.Deleted, ValueProvenance{
+			Path:       path,
+			Source:     winner.Source,
+			Deleted:    true,
+			Overridden: overridden,
+		})
+	}
+
+	return out, nil
+}
+
+// presentAt reports whether path, or a leaf below it, is in the computed values: a later layer
+// may re-create a deleted map.
+func presentAt(present map[string]bool, path string) bool {
+	if present[path] {
+		return true
+	}
+	for leaf := range present {
+		if strings.HasPrefix(leaf, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// maskAssignments returns a copy of the assignments made to path, with secret values masked.
+// An assigned map is masked where it holds secrets, since deleting a parent also deletes them.
+func maskAssignments(path string, history []values.Assignment, secretPaths map[string]values.SecretMode) ([]values.Assignment, error) {
+	out := append([]values.Assignment{}, history...)
+	segments := values.SplitPath(path)
+	if len(secretPaths) == 0 || len(segments) == 0 {
+		return out, nil
+	}
+	for i := range out {
+		if out[i].Deleted {
+			continue
+		}
+		// Place the value at its path so x-secret patterns match as they would in the tree.
+		tree := map[string]any{}
+		node := tree
+		for _, seg := range segments[:len(segments)-1] {
+			child := map[string]any{}
+			node[seg] = child
+			node = child
+		}
+		node[segments[len(segments)-1]] = out[i].Value
+
+		masked, err := values.TransformPaths(tree, secretPaths, func(string, values.SecretMode, any) (any, error) {
+			return maskedValue, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		val := any(masked)
+		for _, seg := range segments {
+			val = val.(map[string]any)[seg]
+		}
+		out[i].Value = val
+	}
+	return out, nil
+}
+
+// resolveChartSource returns the explicit chart source or falls back to the one recorded for
+// the release, pinned to the chart it was rendered from.
+func (a *Application) resolveChartSource(ctx context.Context, opts *RenderOptions) (string, error) {
+	if opts.ChartSource != "" {
+		return opts.ChartSource, nil
+	}
+	_, runtimeDir, err := a.resolveRuntimeLocation(opts.ReleaseName, opts.RuntimeBaseDir, opts.RuntimePath)
+	if err != nil {
+		return "", err
+	}
+	meta, err := a.Runtime.ReleaseStore.Load(ctx, runtimeDir)
+	if err != nil {
+		return "", fmt.Errorf("load release metadata: %w", err)
+	}
+	if meta == nil {
+		return "", fmt.Errorf("--chart is required (release %s doesn't exist yet)", opts.ReleaseName)
+	}
+	if meta.ChartSource == "" {
+		return "", fmt.Errorf("release %s exists but chart source is unknown (provide --chart)", opts.ReleaseName)
+	}
+	pinToRelease(opts, meta.ChartSource, meta)
+	return meta.ChartSource, nil
+}
+
+// walkLeaves visits every scalar, list and empty map in sorted key order.
+func walkLeaves(node map[string]any, path []string, visit func(path []string, val any)) {
+	keys := make([]string, 0, len(node))
+	for k := range node {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		here := append(append([]string{}, path...), k)
+		if child, ok := node[k].(map[string]any); ok && len(child) > 0 {
+			walkLeaves(child, here, visit)
+			continue
+		}
+		visit(here, node[k])
+	}
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"composepack/internal/core/release"
+)
+
+// notesFileName is the name under which rendered chart notes are archived per revision.
+const notesFileName = "NOTES.txt"
+
+// GetOptions select a release (and optionally a historical revision) to inspect.
+type GetOptions struct {
+	ReleaseName    string
+	RuntimeBaseDir string
+	RuntimePath    string
+	Revision       int
+}
+
+// GetValuesOptions control which values `get values` returns.
+type GetValuesOptions struct {
+	GetOptions
+	All    bool // include chart defaults instead of user-supplied overrides only
+	Reveal bool // decrypt sealed secrets using the release key
+}
+
+// GetMetadata returns the stored metadata for the selected release revision.
+func (a *Application) GetMetadata(ctx context.Context, opts GetOptions) (*release.Metadata, error) {
+	meta, _, err := a.loadReleaseRevision(ctx, opts)
+	return meta, err
+}
+
+// GetValues returns the values a release revision was rendered with.
+func (a *Application) GetValues(ctx context.Context, opts GetValuesOptions) (map[string]any, error) {
+	meta, runtimeDir, err := a.loadReleaseRevision(ctx, opts.GetOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := meta.UserValues
+	if opts.All {
+		vals = meta.Values
+	}
+	if vals == nil {
+		vals = map[string]any{}
+	}
+	if !opts.Reveal {
+		return vals, nil
+	}
+
+	revealed, err := a.Runtime.ReleaseStore.Unseal(runtimeDir, vals, meta.SecretPaths)
+	if err != nil {
+		if errors.Is(err, release.ErrKeyUnavailable) {
+			return nil, fmt.Errorf("cannot reveal secrets for release %s: %w", opts.ReleaseName, err)
+		}
+		return nil, err
+	}
+	return revealed, nil
+}
+
+// GetManifest returns the merged docker-compose.yaml stored for a release revision.
+func (a *Application) GetManifest(ctx context.Context, opts GetOptions) ([]byte, error) {
+	meta, runtimeDir, err := a.loadReleaseRevision(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := a.Runtime.ReleaseStore.LoadArtifact(ctx, runtimeDir, meta.Revision, manifestFileName)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil && opts.Revision == 0 {
+		// Releases written before revision history existed only have the live compose file.
+		data, err = os.ReadFile(filepath.Join(runtimeDir, manifestFileName))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("read compose file: %w", err)
+		}
+	}
+	if data == nil {
+		return nil, fmt.Errorf("release %s revision %d has no stored manifest", opts.ReleaseName, meta.Revision)
+	}
+	return data, nil
+}
+
+// GetNotes returns the rendered chart notes stored for a release revision (nil when the chart has none).
+func (a *Application) GetNotes(ctx context.Context, opts GetOptions) ([]byte, error) {
+	meta, runtimeDir, err := a.loadReleaseRevision(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return a.Runtime.ReleaseStore.LoadArtifact(ctx, runtimeDir, meta.Revision, notesFileName)
+}
+
+// printNotes prints the chart notes stored for a release revision, if any.
+func (a *Application) printNotes(ctx context.Context, runtimeDir string, meta *release.Metadata) error {
+	notes, err := a.Runtime.ReleaseStore.LoadArtifact(ctx, runtimeDir, meta.Revision, notesFileName)
+	if err != nil || len(bytes.TrimSpace(notes)) == 0 {
+		return err
+	}
+	fmt.Print(string(notes))
+	if !bytes.HasSuffix(notes, []byte("\n")) {
+		fmt.Println()
+	}
+	return nil
+}
+
+func (a *Application) loadReleaseRevision(ctx context.Context, opts GetOptions) (*release.Metadata, string, error) {
+	_, runtimeDir, err := a.resolveRuntimeLocation(opts.ReleaseName, opts.RuntimeBaseDir, opts.RuntimePath)
+	if err != nil {
+		return nil, "", err
+	}
+	meta, err := a.Runtime.ReleaseStore.LoadRevision(ctx, runtimeDir, opts.Revision)
+	if err != nil {
+		return nil, "", fmt.Errorf("load release metadata: %w", err)
+	}
+	if meta == nil {
+		if opts.Revision > 0 {
+			return nil, "", fmt.Errorf("release %s has no revision %d", opts.ReleaseName, opts.Revision)
+		}
+		return nil, "", fmt.Errorf("release %s not found in %s", opts.ReleaseName, runtimeDir)
+	}
+	return meta, runtimeDir, nil
+}
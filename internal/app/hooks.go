@@ -0,0 +1,210 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/core/dockercompose"
+	"composepack/internal/core/hooks"
+	"composepack/internal/core/release"
+	"composepack/internal/core/templating"
+	"composepack/internal/infra/process"
+)
+
+// UninstallOptions control `composepack uninstall`.
+type UninstallOptions struct {
+	ReleaseName    string
+	RuntimeBaseDir string
+	RuntimePath    string
+	RemoveVolumes  bool
+	NoHooks        bool
+}
+
+// renderHooks renders the templates/hooks of the chart and its dependencies and adds the
+// hook service fragments to fragments (under hooks/), so they are merged into the release
+// compose file.
+func (a *Application) renderHooks(ctx context.Context, ch *chart.Chart, rc templating.RenderContext, fragments *fragmentLayers) (*hooks.Set, error) {
+	set, err := a.renderHookTree(ctx, ch, rc)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(set.Fragments))
+	for name := range set.Fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key := path.Join(hooks.Dir, name)
+		if _, exists := fragments.data[key]; exists {
+			return nil, fmt.Errorf("hook fragment %s collides with compose template %s", name, key)
+		}
+		if err := fragments.add(key, set.Fragments[name]); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// deploy starts a rendered release with `docker compose up`, running the install hooks
+// the first time a release is deployed and the upgrade hooks afterwards. Post hooks need
+// the services running, so they only run for detached deployments; in the foreground they
+// are reported and recorded as skipped. Hook outcomes are
+// recorded in the revision's metadata. The chart notes are printed once the release is up,
+// or just before compose takes over the terminal in the foreground.
+func (a *Application) deploy(ctx context.Context, runtimeDir string, meta *release.Metadata, detach, runHooks bool) error {
+	pre, post := hooks.PreInstall, hooks.PostInstall
+	if meta.Deployed {
+		pre, post = hooks.PreUpgrade, hooks.PostUpgrade
+	}
+	executor := a.hookExecutor(runtimeDir, meta.ReleaseName)
+	args := []string{"up"}
+	if detach {
+		args = append(args, "-d")
+	}
+
+	err := a.runHooks(ctx, executor, meta, pre, runHooks)
+	if err == nil && !detach {
+		err = a.printNotes(ctx, runtimeDir, meta)
+	}
+	if skipped := hooks.Skip(meta.Hooks, post); err == nil && runHooks && !detach && len(skipped) > 0 {
+		names := make([]string, 0, len(skipped))
+		for _, result := range skipped {
+			names = append(names, result.Hook)
+		}
+		a.Runtime.Logger.Warn("skipping %s hooks %s: they only run when the release is started in the background (-d)", post, strings.Join(names, ", "))
+		meta.HookRuns = append(meta.HookRuns, skipped...)
+	}
+	if err == nil {
+		err = a.Runtime.DockerRunner.Run(ctx, dockercompose.CommandOptions{WorkingDir: runtimeDir, Args: args})
+		if err == nil {
+			meta.Deployed = true
+			err = a.runHooks(ctx, executor, meta, post, runHooks && detach)
+		}
+	}
+
+	if saveErr := a.Runtime.ReleaseStore.Save(ctx, runtimeDir, meta); saveErr != nil {
+		return errors.Join(err, fmt.Errorf("save release metadata: %w", saveErr))
+	}
+	if err == nil && detach {
+		err = a.printNotes(ctx, runtimeDir, meta)
+	}
+	return err
+}
+
+// runHooks runs one phase and appends its results to meta.
+func (a *Application) runHooks(ctx context.Context, executor hooks.Executor, meta *release.Metadata, phase hooks.Phase, enabled bool) error {
+	if !enabled {
+		return nil
+	}
+	results, err := hooks.Run(ctx, executor, meta.Hooks, phase)
+	meta.HookRuns = append(meta.HookRuns, results...)
+	return err
+}
+
+// UninstallRelease stops a release and deletes its runtime directory, running the uninstall
+// hooks around `docker compose down`. The directory is kept when a hook aborts.
+func (a *Application) UninstallRelease(ctx context.Context, opts UninstallOptions) error {
+	_, runtimeDir, err := a.resolveRuntimeLocation(opts.ReleaseName, opts.RuntimeBaseDir, opts.RuntimePath)
+	if err != nil {
+		return err
+	}
+	meta, err := a.Runtime.ReleaseStore.Load(ctx, runtimeDir)
+	if err != nil {
+		return fmt.Errorf("load release metadata: %w", err)
+	}
+	if meta == nil {
+		return fmt.Errorf("release %s not found in %s", opts.ReleaseName, runtimeDir)
+	}
+
+	executor := a.hookExecutor(runtimeDir, opts.ReleaseName)
+	down := []string{"down", "--remove-orphans"}
+	if opts.RemoveVolumes {
+		down = append(down, "--volumes")
+	}
+
+	if !opts.NoHooks {
+		if _, err := hooks.Run(ctx, executor, meta.Hooks, hooks.PreUninstall); err != nil {
+			return err
+		}
+	}
+	if err := a.Runtime.DockerRunner.Run(ctx, dockercompose.CommandOptions{WorkingDir: runtimeDir, Args: down}); err != nil {
+		return err
+	}
+	if post := hooks.ForPhase(meta.Hooks, hooks.PostUninstall); !opts.NoHooks && len(post) > 0 {
+		results, err := hooks.Run(ctx, executor, post, hooks.PostUninstall)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			if result.Kind == hooks.KindService {
+				// Service hooks bring up their dependencies and network again.
+				if err := a.Runtime.DockerRunner.Run(ctx, dockercompose.CommandOptions{WorkingDir: runtimeDir, Args: down}); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	if err := os.RemoveAll(runtimeDir); err != nil {
+		return fmt.Errorf("remove release directory: %w", err)
+	}
+	return nil
+}
+
+func (a *Application) hookExecutor(runtimeDir, releaseName string) *hookExecutor {
+	return &hookExecutor{app: a, runtimeDir: runtimeDir, release: releaseName}
+}
+
+// hookExecutor runs hooks from a release directory.
+type hookExecutor struct {
+	app        *Application
+	runtimeDir string
+	release    string
+}
+
+// RunService implements hooks.Executor. The hook's output is streamed to the user.
+func (e *hookExecutor) RunService(ctx context.Context, service string) error {
+	return e.app.Runtime.DockerRunner.Run(ctx, dockercompose.CommandOptions{
+		WorkingDir: e.runtimeDir,
+		Args:       []string{"run", "--rm", service},
+		Stdout:     os.Stdout,
+		Stderr:     os.Stderr,
+	})
+}
+
+// RunScript implements hooks.Executor. Scripts run from the release directory and learn
+// the release, phase and compose project through the environment; their output is streamed
+// to the user.
+func (e *hookExecutor) RunScript(ctx context.Context, script string, phase hooks.Phase) error {
+	// A relative command path would be resolved against Dir a second time.
+	name, err := filepath.Abs(filepath.Join(e.runtimeDir, hooks.Dir, filepath.FromSlash(script)))
+	if err != nil {
+		return err
+	}
+	_, stderr, err := e.app.Runtime.ProcessRunner.Run(ctx, process.Command{
+		Name: name,
+		Dir:  e.runtimeDir,
+		Env: []string{
+			"COMPOSEPACK_RELEASE=" + e.release,
+			"COMPOSEPACK_HOOK_PHASE=" + string(phase),
+			"COMPOSE_PROJECT_NAME=" + e.release,
+		},
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	})
+	if err == nil {
+		return nil
+	}
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"composepack/internal/core/images"
+	"composepack/internal/core/release"
+)
+
+// ImageInfo describes the image one service of a release runs.
+type ImageInfo struct {
+	Service string `json:"service"`
+	Image   string `json:"image"` // repository, without tag or digest
+	Tag     string `json:"tag,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+	Pinned  bool   `json:"pinned"` // the compose file references the digest
+}
+
+// ListImages reports the image of every service in a release revision. Digests of pinned
+// images come from the compose file; others are looked up in the local Docker image store
+// and stay empty when the image is missing there.
+func (a *Application) ListImages(ctx context.Context, opts GetOptions) ([]ImageInfo, error) {
+	compose, err := a.GetManifest(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	list, err := images.List(compose)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []ImageInfo
+	for _, image := range list {
+		name, tag, digest := images.SplitRef(image.Ref)
+		pinned := digest != ""
+		if tag == "" && !pinned {
+			tag = "latest"
+		}
+		if !pinned {
+			if digest, err = a.Runtime.Images.RepoDigest(ctx, image.Ref); err != nil {
+				return nil, err
+			}
+		}
+		for _, service := range image.Services {
+			out = append(out, ImageInfo{Service: service, Image: name, Tag: tag, Digest: digest, Pinned: pinned})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Service < out[j].Service })
+	return out, nil
+}
+
+// pinImageDigests rewrites the images of a merged compose file to name:tag@digest and
+// returns the digest of each service. Digests come from `docker compose config
+// --resolve-image-digests`, which asks the registries; when that fails (offline hosts,
+// images loaded from a bundle or built locally) the local image store is used instead.
+func (a *Application) pinImageDigests(ctx context.Context, fragments *fragmentLayers, files map[string][]byte, releaseName string, merged []byte) ([]byte, []release.ImageDigest, error) {
+	list, err := images.List(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	digests := map[string]string{}
+	resolved, _, err := a.mergeFragments(ctx, fragments, files, releaseName, true)
+	if err != nil {
+		a.Runtime.Logger.Warn("docker compose could not resolve image digests, using local images: %v", err)
+	} else {
+		resolvedList, err := images.List(resolved)
+		if err != nil {
+			return nil, nil, err
+		}
+		byService := map[string]string{}
+		for _, image := range resolvedList {
+			for _, service := range image.Services {
+				byService[service] = image.Ref
+			}
+		}
+		for _, image := range list {
+			if _, _, digest := images.SplitRef(byService[image.Services[0]]); digest != "" {
+				digests[image.Ref] = digest
+			}
+		}
+	}
+
+	var (
+		records []release.ImageDigest
+		missing []string
+	)
+	for _, image := range list {
+		_, _, digest := images.SplitRef(image.Ref)
+		if digest == "" {
+			digest = digests[image.Ref]
+		}
+		if digest == "" {
+			if digest, err = a.Runtime.Images.RepoDigest(ctx, image.Ref); err != nil {
+				return nil, nil, err
+			}
+			if digest == "" {
+				missing = append(missing, image.Ref)
+				continue
+			}
+			digests[image.Ref] = digest
+		}
+		for _, service := range image.Services {
+			records = append(records, release.ImageDigest{Service: service, Image: image.Ref, Digest: digest})
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("cannot pin %s to a digest: pull the image(s) first or make their registry reachable", strings.Join(missing, ", "))
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Service < records[j].Service })
+	return images.Pin(merged, digests), records, nil
+}
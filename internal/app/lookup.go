@@ -0,0 +1,156 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"composepack/internal/core/release"
+	releaseruntime "composepack/internal/core/runtime"
+)
+
+// Kinds accepted by the lookup template function.
+const (
+	lookupRelease  = "release"  // metadata of the current revision
+	lookupValues   = "values"   // its computed values, secrets unsealed
+	lookupServices = "services" // all service definitions of the deployed compose file
+	lookupService  = "service"  // one service definition, by name
+	lookupFile     = "file"     // whether files/<name> exists
+)
+
+// releaseState implements templating.StateLookup over the release as it is on disk before
+// a render: release.json and the runtime directory. Without a release (fresh install,
+// lint) every lookup returns an empty result. Values and services are read on first use.
+type releaseState struct {
+	app        *Application
+	runtimeDir string
+	meta       *release.Metadata // nil on fresh installs
+
+	values   map[string]any
+	services map[string]any
+}
+
+func (a *Application) releaseState(runtimeDir string, meta *release.Metadata) *releaseState {
+	return &releaseState{app: a, runtimeDir: runtimeDir, meta: meta}
+}
+
+// Lookup implements templating.StateLookup.
+func (s *releaseState) Lookup(kind, name string) (any, error) {
+	switch kind {
+	case lookupRelease, lookupValues, lookupServices:
+		if name != "" {
+			return nil, fmt.Errorf("lookup %q takes no name", kind)
+		}
+	case lookupService, lookupFile:
+		if name == "" {
+			return nil, fmt.Errorf("lookup %q needs a name", kind)
+		}
+	default:
+		return nil, fmt.Errorf("unknown lookup kind %q (want %s, %s, %s, %s or %s)",
+			kind, lookupRelease, lookupValues, lookupServices, lookupService, lookupFile)
+	}
+
+	switch kind {
+	case lookupRelease:
+		return s.release(), nil
+	case lookupValues:
+		return s.loadValues()
+	case lookupServices:
+		return s.loadServices()
+	case lookupService:
+		services, err := s.loadServices()
+		if err != nil {
+			return nil, err
+		}
+		if def, ok := services[name].(map[string]any); ok {
+			return def, nil
+		}
+		return map[string]any{}, nil
+	default:
+		return s.fileExists(name)
+	}
+}
+
+func (s *releaseState) release() map[string]any {
+	if s.meta == nil {
+		return map[string]any{}
+	}
+	return map[string]any{
+		"Name":         s.meta.ReleaseName,
+		"Revision":     s.meta.Revision,
+		"ChartName":    s.meta.ChartMetadata.Name,
+		"ChartVersion": s.meta.ChartMetadata.Version,
+		"AppVersion":   s.meta.ChartMetadata.AppVersion,
+		"CreatedAt":    s.meta.CreatedAt,
+		"Deployed":     s.meta.Deployed,
+	}
+}
+
+func (s *releaseState) loadValues() (map[string]any, error) {
+	if s.meta == nil {
+		return map[string]any{}, nil
+	}
+	if s.values != nil {
+		return s.values, nil
+	}
+	vals, err := s.app.Runtime.ReleaseStore.Unseal(s.runtimeDir, deepCopyMap(s.meta.Values), s.meta.SecretPaths)
+	if errors.Is(err, release.ErrKeyUnavailable) {
+		s.app.Runtime.Logger.Warn("lookup values: %v; secret values stay sealed", err)
+		vals, err = deepCopyMap(s.meta.Values), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup values: %w", err)
+	}
+	if vals == nil {
+		vals = map[string]any{}
+	}
+	s.values = vals
+	return vals, nil
+}
+
+func (s *releaseState) loadServices() (map[string]any, error) {
+	if s.meta == nil {
+		return map[string]any{}, nil
+	}
+	if s.services != nil {
+		return s.services, nil
+	}
+	data, err := os.ReadFile(filepath.Join(s.runtimeDir, releaseruntime.ComposeFileName))
+	if err != nil {
+		return nil, fmt.Errorf("lookup services: read current compose file: %w", err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("lookup services: parse current compose file: %w", err)
+	}
+	services, _ := doc["services"].(map[string]any)
+	if services == nil {
+		services = map[string]any{}
+	}
+	s.services = services
+	return services, nil
+}
+
+// fileExists reports whether the release directory holds files/<name>.
+func (s *releaseState) fileExists(name string) (bool, error) {
+	clean := path.Clean(strings.TrimPrefix(name, "files/"))
+	if path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return false, fmt.Errorf("lookup file: invalid path %q", name)
+	}
+	if s.meta == nil {
+		return false, nil
+	}
+	_, err := os.Stat(filepath.Join(s.runtimeDir, releaseruntime.FilesDir, filepath.FromSlash(clean)))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("lookup file: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,310 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"sigs.k8s.io/yaml"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/core/hooks"
+	"composepack/internal/core/images"
+	"composepack/internal/core/release"
+	"composepack/internal/core/templating"
+	"composepack/internal/packager"
+)
+
+// PackageOptions drive `composepack package`: the chart is rendered with its defaults and
+// RenderOptions' values, linted, and only then archived.
+type PackageOptions struct {
+	RenderOptions
+	Package packager.Options
+	// BundleImages saves every image of the rendered compose file into the archive.
+	BundleImages bool
+}
+
+// PackageResult describes a packaged chart and the images bundled into it, if any.
+type PackageResult struct {
+	*packager.Result
+	Images *images.Manifest
+}
+
+// LintSeverity ranks lint findings; errors stop packaging.
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"
+	LintWarning LintSeverity = "warning"
+)
+
+// LintFinding is one problem found in a chart.
+type LintFinding struct {
+	Severity LintSeverity
+	Path     string // chart file the finding refers to
+	Message  string
+}
+
+func (f LintFinding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(string(f.Severity)), f.Path, f.Message)
+}
+
+// PackageChart lints a chart directory and archives it when no errors were found. The
+// findings are returned in both cases.
+func (a *Application) PackageChart(ctx context.Context, opts PackageOptions) (*PackageResult, []LintFinding, error) {
+	ch, err := a.Runtime.ChartLoader.Load(ctx, opts.Package.ChartPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load chart: %w", err)
+	}
+	// Lint what will be shipped: templates see the stamped versions.
+	if opts.Package.Version != "" {
+		ch.Metadata.Version = opts.Package.Version
+	}
+	if opts.Package.AppVersion != "" {
+		ch.Metadata.AppVersion = opts.Package.AppVersion
+	}
+
+	findings, rendered := a.lintChart(ctx, ch, opts.RenderOptions)
+	if errs := countSeverity(findings, LintError); errs > 0 {
+		return nil, findings, fmt.Errorf("chart %s failed validation with %d error(s); nothing was packaged", ch.Metadata.Name, errs)
+	}
+
+	var manifest *images.Manifest
+	if opts.BundleImages {
+		bundleDir, err := os.MkdirTemp("", "composepack-images-*")
+		if err != nil {
+			return nil, findings, fmt.Errorf("create temp directory: %w", err)
+		}
+		defer os.RemoveAll(bundleDir)
+		if manifest, err = a.bundleImages(ctx, ch, rendered, bundleDir); err != nil {
+			return nil, findings, err
+		}
+		extra := map[string]string{}
+		for k, v := range opts.Package.ExtraFiles {
+			extra[k] = v
+		}
+		for _, name := range []string{images.ManifestPath, images.TarballPath} {
+			extra[name] = filepath.Join(bundleDir, filepath.FromSlash(name))
+		}
+		opts.Package.ExtraFiles = extra
+	}
+
+	result, err := packager.PackageChart(ctx, a.Runtime.ChartLoader, opts.Package)
+	if err != nil {
+		return nil, findings, err
+	}
+	return &PackageResult{Result: result, Images: manifest}, findings, nil
+}
+
+// bundleImages merges the rendered fragments with docker compose, then saves every image
+// they reference into dir.
+func (a *Application) bundleImages(ctx context.Context, ch *chart.Chart, rendered *renderedChart, dir string) (*images.Manifest, error) {
+	merged, _, err := a.mergeFragments(ctx, rendered.fragments, rendered.files, ch.Metadata.Name, false)
+	if err != nil {
+		return nil, err
+	}
+	list, err := images.List(merged)
+	if err != nil {
+		return nil, err
+	}
+	return images.Save(ctx, a.Runtime.Images, dir, list)
+}
+
+// LoadBundledImages loads the images bundled in a chart archive into Docker, streaming them
+// straight from the archive. The source is resolved like install resolves it (archive path,
+// URL, `repo/chart` or `oci://` reference); load selects the version and, with a digest, the
+// exact archive a release was rendered from.
+func (a *Application) LoadBundledImages(ctx context.Context, source string, load chart.LoadOptions) (*images.Manifest, error) {
+	resolver, ok := a.Runtime.ChartLoader.(chart.ArchiveResolver)
+	if !ok {
+		return nil, errors.New("chart loader cannot resolve chart archives")
+	}
+	archive, cleanup, err := resolver.ResolveArchive(ctx, source, load)
+	if err != nil {
+		return nil, fmt.Errorf("--load-images: %w", err)
+	}
+	defer cleanup()
+
+	manifest, err := images.Load(ctx, a.Runtime.Images, archive)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("%s bundles no images (package it with --bundle-images)", source)
+	}
+	return manifest, nil
+}
+
+// renderedChart holds the in-memory render produced while linting.
+type renderedChart struct {
+	fragments *fragmentLayers
+	files     map[string][]byte
+}
+
+// LintChart renders a loaded chart in memory, without Docker, using its default values
+// plus the values in opts, and checks the metadata and rendered compose fragments.
+func (a *Application) LintChart(ctx context.Context, ch *chart.Chart, opts RenderOptions) []LintFinding {
+	findings, _ := a.lintChart(ctx, ch, opts)
+	return findings
+}
+
+// lintChart implements LintChart and also returns the render (nil when it failed).
+func (a *Application) lintChart(ctx context.Context, ch *chart.Chart, opts RenderOptions) ([]LintFinding, *renderedChart) {
+	var findings []LintFinding
+	report := func(severity LintSeverity, file, format string, args ...any) {
+		findings = append(findings, LintFinding{Severity: severity, Path: file, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, err := semver.StrictNewVersion(ch.Metadata.Version); err != nil {
+		report(LintError, chart.MetadataFile, "version %q is not a semantic version (MAJOR.MINOR.PATCH)", ch.Metadata.Version)
+	}
+	if ch.Metadata.Description == "" {
+		report(LintWarning, chart.MetadataFile, "description is empty")
+	}
+
+	resolved, err := a.buildValues(ch, opts)
+	if err != nil {
+		report(LintError, chart.ValuesFile, "%v", err)
+		return findings, nil
+	}
+	decls := chartEnvDecls(ch)
+	if undeclaredEnvUse(ch, decls) {
+		report(LintWarning, chart.MetadataFile, "%s", undeclaredEnvWarning)
+	}
+	env, err := resolveChartEnv(decls, resolved.Env, nil)
+	if err != nil {
+		report(LintError, chart.MetadataFile, "%v (pass --env-file to provide them)", err)
+		return findings, nil
+	}
+
+	rc := templating.RenderContext{
+		Values:       resolved.Values,
+		Env:          env.Vars,
+		Release:      releaseInfo(ch.Metadata.Name, "", nil, time.Now()),
+		Chart:        ch.Metadata,
+		Files:        templating.NewFilesAccessor(ch.StaticFiles),
+		Capabilities: a.capabilities(ctx),
+		Secrets:      &release.Secrets{}, // generated values are thrown away
+		State:        a.releaseState("", nil),
+	}
+	fragments, files, err := a.renderChartTree(ctx, ch, rc)
+	if err != nil {
+		report(LintError, "templates", "%v", err)
+		return findings, nil
+	}
+	if _, err := a.renderNotesTree(ctx, ch, rc); err != nil {
+		report(LintError, chart.NotesFile, "%v", err)
+	}
+	if len(fragments.names) == 0 {
+		report(LintError, chart.TemplatesCompose, "chart produced no compose templates")
+		return findings, nil
+	}
+	if _, err := a.renderHooks(ctx, ch, rc, fragments); err != nil {
+		report(LintError, "templates", "%v", err)
+		return findings, nil
+	}
+
+	return append(findings, lintCompose(fragments)...), &renderedChart{fragments: fragments, files: files}
+}
+
+// lintCompose checks rendered fragments: each must be a YAML mapping, and every service
+// (possibly spread over several fragments) needs an image or a build, preferably with a
+// pinned tag. Fragments are read in merge order, so the image that wins is the one checked.
+func lintCompose(fragments *fragmentLayers) []LintFinding {
+	var findings []LintFinding
+	type service struct {
+		fragment string // first fragment defining the service
+		image    string
+		build    bool
+	}
+	services := map[string]*service{}
+
+	for _, name := range fragments.names {
+		file := fragmentPath(name)
+		var doc map[string]any
+		if err := yaml.Unmarshal(fragments.data[name], &doc); err != nil {
+			findings = append(findings, LintFinding{Severity: LintError, Path: file, Message: fmt.Sprintf("rendered output is not valid YAML: %v", err)})
+			continue
+		}
+		raw, ok := doc["services"]
+		if !ok || raw == nil {
+			continue
+		}
+		defs, ok := raw.(map[string]any)
+		if !ok {
+			findings = append(findings, LintFinding{Severity: LintError, Path: file, Message: "services must be a mapping"})
+			continue
+		}
+		for svcName, def := range defs {
+			svc := services[svcName]
+			if svc == nil {
+				svc = &service{fragment: file}
+				services[svcName] = svc
+			}
+			fields, _ := def.(map[string]any)
+			if image, ok := fields["image"].(string); ok && image != "" {
+				svc.image = image
+			}
+			if _, ok := fields["build"]; ok {
+				svc.build = true
+			}
+		}
+	}
+
+	svcNames := make([]string, 0, len(services))
+	for name := range services {
+		svcNames = append(svcNames, name)
+	}
+	sort.Strings(svcNames)
+	for _, name := range svcNames {
+		svc := services[name]
+		switch {
+		case svc.image == "" && !svc.build:
+			findings = append(findings, LintFinding{Severity: LintError, Path: svc.fragment, Message: fmt.Sprintf("service %s has neither image nor build", name)})
+		case svc.image != "" && !strings.Contains(svc.image, "$") && !imagePinned(svc.image):
+			findings = append(findings, LintFinding{Severity: LintWarning, Path: svc.fragment, Message: fmt.Sprintf("service %s uses image %s without a pinned tag", name, svc.image)})
+		}
+	}
+	return findings
+}
+
+// imagePinned reports whether an image reference carries a digest or a tag other than latest.
+func imagePinned(image string) bool {
+	_, tag, digest := images.SplitRef(image)
+	return digest != "" || (tag != "" && tag != "latest")
+}
+
+// fragmentPath maps a rendered fragment name back to its template path in the chart.
+func fragmentPath(name string) string {
+	dir := chart.TemplatesCompose
+	if rest, ok := strings.CutPrefix(name, hooks.Dir+"/"); ok {
+		dir, name = chart.TemplatesHooks, rest
+	}
+	// charts/<alias>/, repeated for nested dependencies
+	var prefix string
+	for strings.HasPrefix(name, chart.ChartsDir+"/") {
+		parts := strings.SplitN(name, "/", 3)
+		if len(parts) < 3 {
+			break
+		}
+		prefix, name = path.Join(prefix, parts[0], parts[1]), parts[2]
+	}
+	return path.Join(prefix, dir, name)
+}
+
+func countSeverity(findings []LintFinding, severity LintSeverity) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
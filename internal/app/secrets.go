@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"composepack/internal/core/release"
+)
+
+// generatedPlaceholder is rendered by diff for secrets genSecret has not generated yet.
+const generatedPlaceholder = "<generated>"
+
+// previewSecrets reads stored genSecret values but renders generatedPlaceholder for the ones
+// that do not exist yet, so `diff` output is deterministic and nothing is stored.
+type previewSecrets struct {
+	store *release.Secrets
+}
+
+// Secret implements templating.SecretGenerator.
+func (p previewSecrets) Secret(name string, length int) (string, error) {
+	if value, ok := p.store.Value(name); ok {
+		return value, nil
+	}
+	if err := release.CheckSecret(name, length); err != nil {
+		return "", err
+	}
+	return generatedPlaceholder, nil
+}
+
+// RotateSecretOptions control `composepack rotate-secret`.
+type RotateSecretOptions struct {
+	ReleaseName    string
+	RuntimeBaseDir string
+	RuntimePath    string
+	Names          []string
+	All            bool
+}
+
+// RotateSecrets replaces values generated by genSecret with new ones and returns the
+// rotated names. Running containers keep the old values until the release is re-rendered
+// (`up`), which also means the services themselves (e.g. a database user) may need updating.
+func (a *Application) RotateSecrets(ctx context.Context, opts RotateSecretOptions) ([]string, error) {
+	if opts.All == (len(opts.Names) > 0) {
+		return nil, errors.New("name the secrets to rotate or pass --all")
+	}
+	_, runtimeDir, err := a.resolveRuntimeLocation(opts.ReleaseName, opts.RuntimeBaseDir, opts.RuntimePath)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := a.Runtime.ReleaseStore.Load(ctx, runtimeDir)
+	if err != nil {
+		return nil, fmt.Errorf("load release metadata: %w", err)
+	}
+	if meta == nil {
+		return nil, fmt.Errorf("release %s not found in %s", opts.ReleaseName, runtimeDir)
+	}
+
+	secrets, err := a.Runtime.ReleaseStore.LoadSecrets(runtimeDir)
+	if err != nil {
+		return nil, err
+	}
+	names := opts.Names
+	if opts.All {
+		if names = secrets.Names(); len(names) == 0 {
+			return nil, fmt.Errorf("release %s has no generated secrets", opts.ReleaseName)
+		}
+	}
+	if err := secrets.Rotate(names...); err != nil {
+		return nil, err
+	}
+	if err := secrets.Save(ctx); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
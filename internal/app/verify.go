@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/core/provenance"
+)
+
+// VerifyChart checks a packaged chart (local archive or URL) against its provenance file
+// and the trusted keys in keyringPath (the configured keyring when empty).
+func (a *Application) VerifyChart(ctx context.Context, source, keyringPath string) (*provenance.Verification, error) {
+	keyring, err := a.loadKeyring(keyringPath)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, prov := source, provenance.Path(source)
+	if chart.IsURL(source) {
+		path, cleanup, err := chart.Fetch(ctx, a.Runtime.ChartCache, source)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		provPath, provCleanup, err := a.Runtime.ChartCache.Fetch(ctx, source+provenance.FileSuffix, "composepack-chart-*"+provenance.FileSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("download provenance: %w", err)
+		}
+		defer provCleanup()
+		archive, prov = path, provPath
+	} else if !chart.IsArchive(source) {
+		return nil, fmt.Errorf("%s is not a chart archive (only packaged charts can be verified)", source)
+	} else if _, err := os.Stat(source); err != nil {
+		return nil, fmt.Errorf("read chart archive: %w", err)
+	}
+	return keyring.VerifyFile(archive, prov)
+}
+
+// loadKeyring reads the trusted public keys from path, or the configured keyring.
+func (a *Application) loadKeyring(path string) (*provenance.Keyring, error) {
+	if path == "" {
+		path = a.Runtime.Config.Keyring
+	}
+	return provenance.LoadKeyring(path)
+}
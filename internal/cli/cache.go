@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+)
+
+// NewCacheCommand returns the `composepack cache` command group.
+func NewCacheCommand(application *app.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and clean the local cache of downloaded charts",
+	}
+
+	cmd.AddCommand(
+		newCacheListCommand(application),
+		newCacheCleanCommand(application),
+	)
+	return cmd
+}
+
+func newCacheListCommand(application *app.Application) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List cached chart archives and the sources they were downloaded from",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache := application.Runtime.ChartCache
+			blobs, err := cache.List()
+			if err != nil {
+				return err
+			}
+			if len(blobs) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "No cached charts in %s\n", cache.Dir)
+				return nil
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "DIGEST\tSIZE\tLAST USED\tSOURCES")
+			for _, blob := range blobs {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+					shortDigest(blob.Digest), formatSize(blob.Size), blob.LastUsed.Format(time.DateTime), dash(strings.Join(blob.Sources, ", ")))
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func newCacheCleanCommand(application *app.Application) *cobra.Command {
+	var maxAge time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove cached chart archives",
+		Long: `Remove cached chart archives (all of them by default, or those unused for --max-age).
+Releases pinned to a removed archive download it again on their next re-render.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			removed, err := application.Runtime.ChartCache.Clean(cmd.Context(), maxAge)
+			var freed int64
+			for _, blob := range removed {
+				freed += blob.Size
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %d cached chart(s), freed %s\n", len(removed), formatSize(freed))
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&maxAge, "max-age", 0, "only remove archives not used for this long (e.g. 720h)")
+	return cmd
+}
+
+// shortDigest abbreviates sha256:<hex> digests for tables.
+func shortDigest(digest string) string {
+	if len(digest) > len("sha256:")+12 {
+		return digest[:len("sha256:")+12]
+	}
+	return digest
+}
+
+// formatSize renders a byte count with binary units.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value, suffix := float64(size), ""
+	for _, s := range []string{"Ki", "Mi", "Gi"} {
+		value, suffix = value/unit, s
+		if value < unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
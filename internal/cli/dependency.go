@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+	"composepack/internal/core/chart"
+	"composepack/internal/dependency"
+)
+
+// NewDependencyCommand returns the `composepack dependency` command group.
+func NewDependencyCommand(application *app.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "dependency",
+		Aliases: []string{"dep"},
+		Short:   "Manage a chart's dependencies (charts/ and Chart.lock)",
+	}
+
+	cmd.AddCommand(
+		newDependencyUpdateCommand(application),
+		newDependencyBuildCommand(application),
+		newDependencyListCommand(application),
+	)
+	return cmd
+}
+
+func newDependencyUpdateCommand(application *app.Application) *cobra.Command {
+	var dlFlags downloadFlags
+
+	cmd := &cobra.Command{
+		Use:   "update [chart-dir]",
+		Short: "Resolve dependency ranges, vendor them under charts/ and write Chart.lock",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := dlFlags.apply(application.Runtime); err != nil {
+				return err
+			}
+			manager := dependency.NewManager(application.Runtime.ChartLoader, application.Runtime.Repositories, application.Runtime.ChartCache)
+			lock, err := manager.Update(cmd.Context(), chartDirArg(args))
+			if err != nil {
+				return err
+			}
+			printLocked(cmd, lock)
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\n", chart.LockFile)
+			return nil
+		},
+	}
+
+	dlFlags.register(cmd)
+	return cmd
+}
+
+func newDependencyBuildCommand(application *app.Application) *cobra.Command {
+	var dlFlags downloadFlags
+
+	cmd := &cobra.Command{
+		Use:   "build [chart-dir]",
+		Short: "Restore charts/ from the versions pinned in Chart.lock",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := dlFlags.apply(application.Runtime); err != nil {
+				return err
+			}
+			manager := dependency.NewManager(application.Runtime.ChartLoader, application.Runtime.Repositories, application.Runtime.ChartCache)
+			lock, err := manager.Build(cmd.Context(), chartDirArg(args))
+			if err != nil {
+				return err
+			}
+			printLocked(cmd, lock)
+			return nil
+		},
+	}
+
+	dlFlags.register(cmd)
+	return cmd
+}
+
+func newDependencyListCommand(application *app.Application) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [chart-dir]",
+		Short: "List declared dependencies and whether charts/ satisfies them",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager := dependency.NewManager(application.Runtime.ChartLoader, application.Runtime.Repositories, application.Runtime.ChartCache)
+			entries, err := manager.List(cmd.Context(), chartDirArg(args))
+			if err != nil {
+				return err
+			}
+
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tVERSION\tREPOSITORY\tLOCKED\tVENDORED\tSTATUS")
+			for _, e := range entries {
+				name := e.Name
+				if e.Alias != "" {
+					name = fmt.Sprintf("%s (%s)", e.Alias, e.Name)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", name, dash(e.Version), dash(e.Repository), dash(e.Locked), dash(e.Vendored), e.Status)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func printLocked(cmd *cobra.Command, lock *chart.Lock) {
+	for _, dep := range lock.Dependencies {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s from %s (%s)\n", dep.Key(), dep.Version, dep.Repository, dep.Digest)
+	}
+}
+
+func chartDirArg(args []string) string {
+	if len(args) == 0 {
+		return "."
+	}
+	return args[0]
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -9,9 +9,13 @@ import (
 // NewDiffCommand returns the `composepack diff` command.
 func NewDiffCommand(application *app.Application) *cobra.Command {
 	var (
-		valueFiles   []string
-		setValues    []string
+		valFlags     valuesFlags
 		chartSrc     string
+		chartVersion string
+		chartDigest  string
+		pinDigests   bool
+		dlFlags      downloadFlags
+		vfyFlags     verifyFlags
 		runtimeDir   string
 		showFiles    bool
 		contextLines int
@@ -34,13 +38,11 @@ If the release doesn't exist, --chart is required to show what would be created.
 This helps answer: "If I run install now, will it restart my database?"`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			overrides, err := parseSetFlags(setValues)
+			releaseDir, err := cmd.Flags().GetString("release-dir")
 			if err != nil {
 				return err
 			}
-
-			releaseDir, err := cmd.Flags().GetString("release-dir")
-			if err != nil {
+			if err := dlFlags.apply(application.Runtime); err != nil {
 				return err
 			}
 
@@ -48,8 +50,9 @@ This helps answer: "If I run install now, will it restart my database?"`,
 				RenderOptions: app.RenderOptions{
 					ReleaseName:    args[0],
 					ChartSource:    chartSrc,
-					ValueFiles:     append([]string{}, valueFiles...),
-					SetValues:      overrides,
+					ChartVersion:   chartVersion,
+					ChartDigest:    chartDigest,
+					PinDigests:     pinDigests,
 					RuntimeBaseDir: releaseDir,
 					RuntimePath:    runtimeDir,
 				},
@@ -57,13 +60,20 @@ This helps answer: "If I run install now, will it restart my database?"`,
 				ContextLines: contextLines,
 			}
 
+			valFlags.apply(&opts.RenderOptions)
+			vfyFlags.apply(&opts.RenderOptions)
+
 			return application.DiffRelease(cmd.Context(), opts)
 		},
 	}
 
 	cmd.Flags().StringVar(&chartSrc, "chart", "", "chart directory or archive to compare (auto-resolved from release if omitted)")
-	cmd.Flags().StringArrayVarP(&valueFiles, "values", "f", nil, "values files to include")
-	cmd.Flags().StringArrayVar(&setValues, "set", nil, "direct values to set (key=value)")
+	cmd.Flags().StringVar(&chartVersion, "version", "", "chart version range (e.g. ~1.2) for repository charts such as repo/chart")
+	cmd.Flags().StringVar(&chartDigest, "verify-digest", "", "fail unless the chart archive has this sha256:<hex> digest")
+	cmd.Flags().BoolVar(&pinDigests, "pin-digests", false, "pin images to their registry digests before comparing (as install --pin-digests would)")
+	valFlags.register(cmd)
+	dlFlags.register(cmd)
+	vfyFlags.register(cmd)
 	cmd.Flags().StringVar(&runtimeDir, "runtime-dir", "", "path to existing release directory (overrides --release-dir, advanced use only)")
 	cmd.Flags().BoolVar(&showFiles, "show-files", false, "show diffs for changed files in addition to compose")
 	cmd.Flags().IntVarP(&contextLines, "context", "C", 3, "number of context lines in diff output")
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+)
+
+// NewExplainValuesCommand returns the `composepack explain-values` command.
+func NewExplainValuesCommand(application *app.Application) *cobra.Command {
+	var (
+		valFlags   valuesFlags
+		chartSrc   string
+		runtimeDir string
+		output     string
+		reveal     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "explain-values <release>",
+		Short: "Show where every value of a release comes from",
+		Long: `Merge values exactly like install/up would and print each leaf with the source
+that set it (chart defaults, a -f file or a --set flag) and the sources it overrode.
+
+If the release exists, the chart source is auto-resolved from the release metadata.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			releaseDir, err := cmd.Flags().GetString("release-dir")
+			if err != nil {
+				return err
+			}
+
+			opts := app.ExplainOptions{
+				RenderOptions: app.RenderOptions{
+					ReleaseName:    args[0],
+					ChartSource:    chartSrc,
+					RuntimeBaseDir: releaseDir,
+					RuntimePath:    runtimeDir,
+				},
+				Reveal: reveal,
+			}
+			valFlags.apply(&opts.RenderOptions)
+
+			explanation, err := application.ExplainValues(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+
+			switch output {
+			case "", "text":
+				return printExplanation(cmd.OutOrStdout(), explanation)
+			case "json":
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetEscapeHTML(false)
+				enc.SetIndent("", "  ")
+				return enc.Encode(explanation)
+			default:
+				return fmt.Errorf("unsupported output format %q (use text or json)", output)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&chartSrc, "chart", "", "chart directory or archive (auto-resolved from release if omitted)")
+	valFlags.register(cmd)
+	cmd.Flags().StringVar(&runtimeDir, "runtime-dir", "", "path to existing release directory (overrides --release-dir)")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "output format (text or json)")
+	cmd.Flags().BoolVar(&reveal, "reveal", false, "show values marked x-secret in values.schema.json")
+
+	return cmd
+}
+
+func printExplanation(w io.Writer, e *app.ValuesExplanation) error {
+	fmt.Fprintf(w, "Release: %s\nChart:   %s\nLayers:  ", e.ReleaseName, e.ChartSource)
+	for i, src := range e.Sources {
+		if i > 0 {
+			fmt.Fprint(w, " < ")
+		}
+		fmt.Fprint(w, src)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w)
+
+	for _, leaf := range e.Leaves {
+		fmt.Fprintf(w, "%s = %s  [%s]\n", leaf.Path, formatValue(leaf.Value), leaf.Source)
+		for i := len(leaf.Overridden) - 1; i >= 0; i-- {
+			prev := leaf.Overridden[i]
+			if prev.Deleted {
+				fmt.Fprintf(w, "    overrides deletion by %s\n", prev.Source)
+				continue
+			}
+			fmt.Fprintf(w, "    overrides %s from %s\n", formatValue(prev.Value), prev.Source)
+		}
+	}
+
+	if len(e.Deleted) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Deleted keys:")
+		for _, leaf := range e.Deleted {
+			fmt.Fprintf(w, "%s  [deleted by %s]\n", leaf.Path, leaf.Source)
+		}
+	}
+	return nil
+}
+
+func formatValue(v any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"composepack/internal/app"
+)
+
+// NewGetCommand groups the `composepack get` subcommands that inspect stored releases.
+func NewGetCommand(application *app.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Inspect what a release actually deployed",
+	}
+
+	cmd.AddCommand(
+		newGetValuesCommand(application),
+		newGetManifestCommand(application),
+		newGetMetadataCommand(application),
+		newGetNotesCommand(application),
+	)
+
+	return cmd
+}
+
+type getFlags struct {
+	revision   int
+	runtimeDir string
+}
+
+func (f *getFlags) register(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&f.revision, "revision", 0, "release revision to inspect (defaults to the current revision)")
+	cmd.Flags().StringVar(&f.runtimeDir, "runtime-dir", "", "path to release directory (overrides --release-dir)")
+}
+
+func (f *getFlags) options(cmd *cobra.Command, releaseName string) (app.GetOptions, error) {
+	releaseDir, err := cmd.Flags().GetString("release-dir")
+	if err != nil {
+		return app.GetOptions{}, err
+	}
+	return app.GetOptions{
+		ReleaseName:    releaseName,
+		RuntimeBaseDir: releaseDir,
+		RuntimePath:    f.runtimeDir,
+		Revision:       f.revision,
+	}, nil
+}
+
+func newGetValuesCommand(application *app.Application) *cobra.Command {
+	var (
+		flags  getFlags
+		all    bool
+		reveal bool
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "values <release>",
+		Short: "Print the values a release was rendered with",
+		Long: `Print the values stored for a release revision.
+
+By default only user-supplied values (-f files and --set flags) are shown; use --all
+to include chart defaults. Values marked "x-secret" in values.schema.json are stored
+encrypted and shown sealed unless --reveal is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.options(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			vals, err := application.GetValues(cmd.Context(), app.GetValuesOptions{
+				GetOptions: opts,
+				All:        all,
+				Reveal:     reveal,
+			})
+			if err != nil {
+				return err
+			}
+			return printStructured(cmd.OutOrStdout(), vals, output)
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().BoolVar(&all, "all", false, "show computed values including chart defaults")
+	cmd.Flags().BoolVar(&reveal, "reveal", false, "decrypt secret values using the release key")
+	cmd.Flags().StringVarP(&output, "output", "o", "yaml", "output format (yaml or json)")
+
+	return cmd
+}
+
+func newGetManifestCommand(application *app.Application) *cobra.Command {
+	var flags getFlags
+
+	cmd := &cobra.Command{
+		Use:   "manifest <release>",
+		Short: "Print the merged docker-compose.yaml of a release",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.options(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			data, err := application.GetManifest(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+
+	flags.register(cmd)
+	return cmd
+}
+
+func newGetMetadataCommand(application *app.Application) *cobra.Command {
+	var (
+		flags  getFlags
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "metadata <release>",
+		Short: "Print the release metadata (chart, revision, value sources)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.options(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			meta, err := application.GetMetadata(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			return printStructured(cmd.OutOrStdout(), meta, output)
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().StringVarP(&output, "output", "o", "yaml", "output format (yaml or json)")
+	return cmd
+}
+
+// NewNotesCommand defines `composepack notes`, a shortcut for `composepack get notes`.
+func NewNotesCommand(application *app.Application) *cobra.Command {
+	return newGetNotesCommand(application)
+}
+
+func newGetNotesCommand(application *app.Application) *cobra.Command {
+	var flags getFlags
+
+	cmd := &cobra.Command{
+		Use:   "notes <release>",
+		Short: "Print the rendered chart notes (templates/NOTES.txt) of a release",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.options(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			data, err := application.GetNotes(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if len(data) == 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "release %s has no notes\n", args[0])
+				return nil
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+
+	flags.register(cmd)
+	return cmd
+}
+
+func printStructured(w io.Writer, v any, format string) error {
+	switch format {
+	case "", "yaml":
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	default:
+		return fmt.Errorf("unsupported output format %q (use yaml or json)", format)
+	}
+}
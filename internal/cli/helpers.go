@@ -2,26 +2,115 @@ package cli
 
 import (
 	"fmt"
-	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+	"composepack/internal/infra/download"
 )
 
-func parseSetFlags(values []string) (map[string]string, error) {
-	if len(values) == 0 {
-		return map[string]string{}, nil
-	}
+// valuesFlags holds the values-related flags shared by install/template/up/diff.
+type valuesFlags struct {
+	valueFiles  []string
+	setValues   []string
+	setStrings  []string
+	setJSON     []string
+	setFiles    []string
+	setLiterals []string
+	envFiles    []string
+}
+
+func (f *valuesFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&f.valueFiles, "values", "f", nil, "values files to include (can specify multiple)")
+	cmd.Flags().StringArrayVar(&f.setValues, "set", nil, "set values on the command line (a.b=1,list[0]=x,tags={a,b}; null deletes a key)")
+	cmd.Flags().StringArrayVar(&f.setStrings, "set-string", nil, "set STRING values on the command line (key=value)")
+	cmd.Flags().StringArrayVar(&f.setJSON, "set-json", nil, "set JSON values on the command line (key=<json>)")
+	cmd.Flags().StringArrayVar(&f.setFiles, "set-file", nil, "set values from files (key=path); the file contents become the value")
+	cmd.Flags().StringArrayVar(&f.setLiterals, "set-literal", nil, "set a literal STRING value without escaping or splitting (key=value)")
+	cmd.Flags().StringArrayVar(&f.envFiles, "env-file", nil, "dotenv files providing .Env and COMPOSEPACK_VALUE_* / x-env values (process env wins)")
+}
+
+// apply copies the parsed flag values onto render options.
+func (f *valuesFlags) apply(opts *app.RenderOptions) {
+	opts.ValueFiles = append([]string{}, f.valueFiles...)
+	opts.SetValues = append([]string{}, f.setValues...)
+	opts.SetStrings = append([]string{}, f.setStrings...)
+	opts.SetJSON = append([]string{}, f.setJSON...)
+	opts.SetFiles = append([]string{}, f.setFiles...)
+	opts.SetLiterals = append([]string{}, f.setLiterals...)
+	opts.EnvFiles = append([]string{}, f.envFiles...)
+}
+
+// downloadFlags holds the HTTP download settings shared by commands that fetch charts or
+// repository indexes.
+type downloadFlags struct {
+	username              string
+	password              string
+	token                 string
+	caFile                string
+	certFile              string
+	keyFile               string
+	insecureSkipTLSVerify bool
+	timeout               time.Duration
+	retries               int
+	maxSize               string
+	offline               bool
+}
 
-	out := make(map[string]string, len(values))
-	for _, raw := range values {
-		if raw == "" {
-			continue
-		}
-
-		parts := strings.SplitN(raw, "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid --set value %q; must be key=value", raw)
-		}
-		out[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+func (f *downloadFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.username, "username", "", "username for HTTP basic auth on chart and index downloads")
+	cmd.Flags().StringVar(&f.password, "password", "", "password for HTTP basic auth on chart and index downloads")
+	cmd.Flags().StringVar(&f.token, "token", "", "bearer token for chart and index downloads")
+	cmd.Flags().StringVar(&f.caFile, "ca-file", "", "PEM bundle of extra CAs to trust for downloads")
+	cmd.Flags().StringVar(&f.certFile, "cert-file", "", "client certificate (PEM) for downloads")
+	cmd.Flags().StringVar(&f.keyFile, "key-file", "", "client key (PEM) for downloads")
+	cmd.Flags().BoolVar(&f.insecureSkipTLSVerify, "insecure-skip-tls-verify", false, "skip TLS certificate verification for downloads")
+	cmd.Flags().DurationVar(&f.timeout, "download-timeout", download.DefaultTimeout, "timeout for each download attempt")
+	cmd.Flags().IntVar(&f.retries, "download-retries", download.DefaultRetries, "retries after network errors, 429 and 5xx responses")
+	cmd.Flags().StringVar(&f.maxSize, "max-download-size", "100Mi", "maximum size of a download (bytes or Ki/Mi/Gi; 0 disables the limit)")
+	cmd.Flags().BoolVar(&f.offline, "offline", false, "never touch the network; use cached charts and repository indexes only")
+}
+
+// apply copies the parsed flag values onto the runtime's download and registry clients.
+func (f *downloadFlags) apply(rt *app.Runtime) error {
+	maxSize, err := download.ParseSize(f.maxSize)
+	if err != nil {
+		return fmt.Errorf("--max-download-size: %w", err)
+	}
+	if f.password != "" && f.username == "" {
+		return fmt.Errorf("--password requires --username")
 	}
+	rt.Registry.Offline = f.offline
+	rt.Downloader.Options = download.Options{
+		Username:              f.username,
+		Password:              f.password,
+		Token:                 f.token,
+		CAFile:                f.caFile,
+		CertFile:              f.certFile,
+		KeyFile:               f.keyFile,
+		InsecureSkipTLSVerify: f.insecureSkipTLSVerify,
+		Timeout:               f.timeout,
+		Retries:               f.retries,
+		MaxSize:               maxSize,
+		Offline:               f.offline,
+	}
+	return nil
+}
+
+// verifyFlags holds the chart signature flags shared by install/template/up/diff.
+type verifyFlags struct {
+	verify  bool
+	keyring string
+}
+
+func (f *verifyFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.verify, "verify", false, "require a valid signature (<archive>.prov) before extracting the chart")
+	cmd.Flags().StringVar(&f.keyring, "keyring", "", "PEM file of trusted Ed25519 public keys (defaults to the configured keyring)")
+}
 
-	return out, nil
+// apply copies the parsed flag values onto render options.
+func (f *verifyFlags) apply(opts *app.RenderOptions) {
+	opts.Verify = f.verify
+	opts.Keyring = f.keyring
 }
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+)
+
+// NewImagesCommand defines `composepack images`, listing the images a release runs.
+func NewImagesCommand(application *app.Application) *cobra.Command {
+	var (
+		flags  getFlags
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "images <release>",
+		Short: "List the image, tag and digest of every service in a release",
+		Long: `List the image, tag and digest of every service in a release revision.
+
+Releases rendered with --pin-digests reference their digests directly (PINNED yes).
+For other releases the digest of the local image is shown; it is empty when the image
+has not been pulled yet.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := flags.options(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			list, err := application.ListImages(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if output != "table" {
+				return printStructured(cmd.OutOrStdout(), list, output)
+			}
+
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "SERVICE\tIMAGE\tTAG\tDIGEST\tPINNED")
+			for _, image := range list {
+				pinned := "no"
+				if image.Pinned {
+					pinned = "yes"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", image.Service, image.Image, dash(image.Tag), dash(image.Digest), pinned)
+			}
+			return tw.Flush()
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format (table, yaml or json)")
+	return cmd
+}
@@ -11,15 +11,22 @@ import (
 // NewInstallCommand returns the `composepack install` Cobra command skeleton.
 func NewInstallCommand(application *app.Application) *cobra.Command {
 	var (
-		releaseName string
-		valueFiles  []string
-		setValues   []string
-		autoStart   bool
+		releaseName  string
+		chartVersion string
+		chartDigest  string
+		pinDigests   bool
+		dlFlags      downloadFlags
+		vfyFlags     verifyFlags
+		valFlags     valuesFlags
+		autoStart    bool
+		loadImages   bool
+		noHooks      bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "install <chart>",
 		Short: "Install a chart into a named release runtime",
+		Long:  "Install a chart from a directory, archive, URL or repository reference (repo/chart).",
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return fmt.Errorf("chart source path must be specified")
@@ -32,13 +39,11 @@ func NewInstallCommand(application *app.Application) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			chartSource := args[0]
 
-			overrides, err := parseSetFlags(setValues)
+			releaseDir, err := cmd.Flags().GetString("release-dir")
 			if err != nil {
 				return err
 			}
-
-			releaseDir, err := cmd.Flags().GetString("release-dir")
-			if err != nil {
+			if err := dlFlags.apply(application.Runtime); err != nil {
 				return err
 			}
 
@@ -46,21 +51,33 @@ func NewInstallCommand(application *app.Application) *cobra.Command {
 				RenderOptions: app.RenderOptions{
 					ReleaseName:    releaseName,
 					ChartSource:    chartSource,
-					ValueFiles:     append([]string{}, valueFiles...),
-					SetValues:      overrides,
+					ChartVersion:   chartVersion,
+					ChartDigest:    chartDigest,
+					PinDigests:     pinDigests,
 					RuntimeBaseDir: releaseDir,
 				},
-				AutoStart: autoStart,
+				AutoStart:  autoStart,
+				LoadImages: loadImages,
+				NoHooks:    noHooks,
 			}
 
+			valFlags.apply(&opts.RenderOptions)
+			vfyFlags.apply(&opts.RenderOptions)
+
 			return application.InstallRelease(cmd.Context(), opts)
 		},
 	}
 
 	cmd.Flags().StringVar(&releaseName, "name", "", "release name to use for the installation")
-	cmd.Flags().StringArrayVarP(&valueFiles, "values", "f", nil, "values files to include (can specify multiple)")
-	cmd.Flags().StringArrayVar(&setValues, "set", nil, "direct value overrides (key=value)")
+	cmd.Flags().StringVar(&chartVersion, "version", "", "chart version range (e.g. ~1.2) for repository charts such as repo/chart")
+	cmd.Flags().StringVar(&chartDigest, "verify-digest", "", "fail unless the chart archive has this sha256:<hex> digest")
+	cmd.Flags().BoolVar(&pinDigests, "pin-digests", false, "pin every image to its registry digest and record the digests in release.json")
+	valFlags.register(cmd)
+	dlFlags.register(cmd)
+	vfyFlags.register(cmd)
 	cmd.Flags().BoolVar(&autoStart, "auto-start", false, "run docker compose up after installation")
+	cmd.Flags().BoolVar(&loadImages, "load-images", false, "docker load the images bundled in the chart archive (package --bundle-images) before starting")
+	cmd.Flags().BoolVar(&noHooks, "no-hooks", false, "skip the chart's install/upgrade hooks when --auto-start is set")
 
 	return cmd
 }
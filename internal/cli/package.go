@@ -1,38 +1,97 @@
 package cli
 
 import (
+	"crypto/ed25519"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"composepack/internal/app"
+	"composepack/internal/core/provenance"
 	"composepack/internal/packager"
 )
 
 // NewPackageCommand creates chart archives (.cpack.tgz).
 func NewPackageCommand(application *app.Application) *cobra.Command {
 	var (
-		destination string
-		outputName  string
-		force       bool
+		destination  string
+		outputName   string
+		force        bool
+		sign         bool
+		keyPath      string
+		reproducible bool
+		list         bool
+		version      string
+		appVersion   string
+		valFlags     valuesFlags
+		bundleImages bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "package <chart-dir>",
 		Short: "Package a chart directory into a .cpack.tgz archive",
-		Args:  cobra.ExactArgs(1),
+		Long: `Render the chart with its default values (plus any -f/--set values), lint the result and
+package it into a .cpack.tgz archive. Nothing is written when validation finds errors.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts := packager.Options{
-				ChartPath:   args[0],
-				Destination: destination,
-				OutputName:  outputName,
-				Force:       force,
+			if list {
+				files, err := packager.List(args[0])
+				if err != nil {
+					return err
+				}
+				for _, file := range files {
+					fmt.Fprintln(cmd.OutOrStdout(), file)
+				}
+				return nil
 			}
-			path, err := packager.PackageChart(cmd.Context(), application.Runtime.ChartLoader, opts)
+			if sign && keyPath == "" {
+				return fmt.Errorf("--sign requires --key")
+			}
+			var key ed25519.PrivateKey
+			if sign {
+				// Load the key first so a bad key does not leave an unsigned archive behind.
+				loaded, err := provenance.LoadPrivateKey(keyPath)
+				if err != nil {
+					return err
+				}
+				key = loaded
+			}
+
+			opts := app.PackageOptions{
+				Package: packager.Options{
+					ChartPath:    args[0],
+					Destination:  destination,
+					OutputName:   outputName,
+					Force:        force,
+					Reproducible: reproducible,
+					Checksum:     true,
+					Version:      version,
+					AppVersion:   appVersion,
+				},
+				BundleImages: bundleImages,
+			}
+			valFlags.apply(&opts.RenderOptions)
+
+			result, findings, err := application.PackageChart(cmd.Context(), opts)
+			for _, finding := range findings {
+				fmt.Fprintln(cmd.ErrOrStderr(), finding)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Created %s\nDigest: %s\n", result.Path, result.Digest)
+			if result.Images != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "Bundled %d image(s): %s\n", len(result.Images.Images), strings.Join(result.Images.Refs(), ", "))
+			}
+			if !sign {
+				return nil
+			}
+			provPath, signed, err := provenance.Sign(cmd.Context(), result.Path, key)
 			if err != nil {
 				return err
 			}
-			fmt.Fprintf(cmd.OutOrStdout(), "Created %s\n", path)
+			fmt.Fprintf(cmd.OutOrStdout(), "Signed %s with key %s\n", provPath, signed.KeyID)
 			return nil
 		},
 	}
@@ -40,6 +99,14 @@ func NewPackageCommand(application *app.Application) *cobra.Command {
 	cmd.Flags().StringVarP(&destination, "destination", "d", ".", "output directory for the packaged chart")
 	cmd.Flags().StringVarP(&outputName, "output", "o", "", "output filename (defaults to <name>-<version>.cpack.tgz)")
 	cmd.Flags().BoolVar(&force, "force", false, "overwrite existing output file")
+	cmd.Flags().BoolVar(&reproducible, "reproducible", true, "build a byte-for-byte reproducible archive (timestamps from SOURCE_DATE_EPOCH)")
+	cmd.Flags().StringVar(&version, "version", "", "set the chart version in the packaged Chart.yaml (the source is not modified)")
+	cmd.Flags().StringVar(&appVersion, "app-version", "", "set appVersion in the packaged Chart.yaml (the source is not modified)")
+	valFlags.register(cmd)
+	cmd.Flags().BoolVar(&bundleImages, "bundle-images", false, "docker save every image of the rendered chart into the archive for air-gapped installs")
+	cmd.Flags().BoolVar(&list, "list", false, "print the files that would be packaged (after .cpackignore) without writing an archive")
+	cmd.Flags().BoolVar(&sign, "sign", false, "write a signed provenance file (<archive>.prov) next to the archive")
+	cmd.Flags().StringVar(&keyPath, "key", "", "Ed25519 private key (PKCS#8 PEM) used by --sign")
 
 	return cmd
 }
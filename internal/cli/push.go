@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+	"composepack/internal/core/chart"
+	"composepack/internal/core/oci"
+)
+
+// NewPushCommand uploads a packaged chart to an OCI registry.
+func NewPushCommand(application *app.Application) *cobra.Command {
+	var plainHTTP bool
+
+	cmd := &cobra.Command{
+		Use:   "push <archive> oci://<registry>/<namespace>",
+		Short: "Push a packaged chart (.cpack.tgz) to an OCI registry",
+		Long: `Upload a packaged chart as an OCI artifact to <namespace>/<chart name>:<chart version>.
+Credentials are read from the docker config (docker login / credential helpers). A
+provenance file next to the archive (from package --sign) is pushed with it.
+
+Install it later with: composepack install oci://<registry>/<namespace>/<chart> --version <range>`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archive, target := args[0], args[1]
+			if !chart.IsArchive(archive) {
+				return fmt.Errorf("%s is not a chart archive (run `composepack package` first)", archive)
+			}
+
+			chartYAML, meta, err := chart.ReadArchiveMetadata(archive)
+			if err != nil {
+				return fmt.Errorf("read chart archive: %w", err)
+			}
+			data, err := os.ReadFile(archive)
+			if err != nil {
+				return fmt.Errorf("read chart archive: %w", err)
+			}
+
+			// A signature produced by `package --sign` travels with the chart.
+			provenance, err := os.ReadFile(archive + chart.ProvenanceSuffix)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("read provenance: %w", err)
+			}
+
+			registry := application.Runtime.Registry
+			if plainHTTP {
+				registry.PlainHTTP = true
+			}
+			result, err := registry.Push(cmd.Context(), target, oci.PushOptions{
+				Name:       meta.Name,
+				Version:    meta.Version,
+				ChartYAML:  chartYAML,
+				Archive:    data,
+				Provenance: provenance,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Pushed %s\nDigest: %s\n", result.Ref, result.Digest)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&plainHTTP, "plain-http", false, "use insecure HTTP for the registry (always used for localhost)")
+	return cmd
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+	"composepack/internal/core/repo"
+)
+
+// NewRepoCommand returns the `composepack repo` command group.
+func NewRepoCommand(application *app.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo",
+		Short: "Add, list, remove and update chart repositories",
+	}
+
+	cmd.AddCommand(
+		newRepoAddCommand(application),
+		newRepoListCommand(application),
+		newRepoRemoveCommand(application),
+		newRepoUpdateCommand(application),
+		newRepoIndexCommand(application),
+	)
+	return cmd
+}
+
+func newRepoAddCommand(application *app.Application) *cobra.Command {
+	var (
+		force   bool
+		dlFlags downloadFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add <name> <url>",
+		Short: "Add a chart repository and download its index",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := dlFlags.apply(application.Runtime); err != nil {
+				return err
+			}
+			entry := &repo.Entry{Name: args[0], URL: args[1]}
+			if err := application.Runtime.Repositories.Add(cmd.Context(), entry, force); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%q has been added to your repositories\n", entry.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force-update", false, "replace the repository if it already exists with a different URL")
+	dlFlags.register(cmd)
+	return cmd
+}
+
+func newRepoListCommand(application *app.Application) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List chart repositories",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := application.Runtime.Repositories.List()
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No repositories configured (use `composepack repo add`)")
+				return nil
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tURL")
+			for _, entry := range entries {
+				fmt.Fprintf(tw, "%s\t%s\n", entry.Name, entry.URL)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func newRepoRemoveCommand(application *app.Application) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <name> [name...]",
+		Aliases: []string{"rm"},
+		Short:   "Remove chart repositories",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range args {
+				if err := application.Runtime.Repositories.Remove(name); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%q has been removed from your repositories\n", name)
+			}
+			return nil
+		},
+	}
+}
+
+func newRepoUpdateCommand(application *app.Application) *cobra.Command {
+	var dlFlags downloadFlags
+
+	cmd := &cobra.Command{
+		Use:   "update [name...]",
+		Short: "Download the latest index of chart repositories",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := dlFlags.apply(application.Runtime); err != nil {
+				return err
+			}
+			updated, err := application.Runtime.Repositories.Update(cmd.Context(), args...)
+			if err != nil {
+				return err
+			}
+			for _, entry := range updated {
+				fmt.Fprintf(cmd.OutOrStdout(), "Updated %s (%s)\n", entry.Name, entry.URL)
+			}
+			return nil
+		},
+	}
+
+	dlFlags.register(cmd)
+	return cmd
+}
+
+func newRepoIndexCommand(application *app.Application) *cobra.Command {
+	var (
+		baseURL string
+		merge   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "index <dir>",
+		Short: "Generate index.yaml for a directory of packaged charts",
+		Long: `Scan <dir> for *.cpack.tgz archives and write <dir>/index.yaml so the directory can be
+served as a chart repository from any static HTTP server or bucket.
+
+With --merge, entries of an existing index are kept and archives it already lists are
+not re-read, so CI can publish new versions incrementally.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := args[0]
+
+			var existing *repo.IndexFile
+			if merge != "" {
+				idx, err := repo.LoadIndex(merge)
+				switch {
+				case err == nil:
+					existing = idx
+				case !errors.Is(err, fs.ErrNotExist):
+					return fmt.Errorf("load %s: %w", merge, err)
+				}
+			}
+
+			result, err := repo.IndexDirectory(cmd.Context(), application.Runtime.ChartLoader, dir, baseURL, existing)
+			if err != nil {
+				return err
+			}
+			out := filepath.Join(dir, repo.IndexFileName)
+			if err := result.Index.WriteFile(out); err != nil {
+				return err
+			}
+
+			for _, cv := range result.Added {
+				fmt.Fprintf(cmd.OutOrStdout(), "Added %s %s\n", cv.Name, cv.Version)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s (%d added, %d unchanged)\n", out, len(result.Added), result.Skipped)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&baseURL, "url", "", "base URL of the repository, prefixed to chart URLs")
+	cmd.Flags().StringVar(&merge, "merge", "", "existing index.yaml to merge into (its entries win)")
+	return cmd
+}
+
+// NewSearchCommand returns the `composepack search` command group.
+func NewSearchCommand(application *app.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search",
+		Short: "Search for charts",
+	}
+	cmd.AddCommand(newSearchRepoCommand(application))
+	return cmd
+}
+
+func newSearchRepoCommand(application *app.Application) *cobra.Command {
+	var versions bool
+
+	cmd := &cobra.Command{
+		Use:   "repo [term]",
+		Short: "Search chart names and descriptions in the cached repository indexes",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			term := ""
+			if len(args) == 1 {
+				term = args[0]
+			}
+			results, err := application.Runtime.Repositories.Search(cmd.Context(), term, versions)
+			if err != nil {
+				return err
+			}
+			if len(results) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No results found")
+				return nil
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tCHART VERSION\tDESCRIPTION")
+			for _, r := range results {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Name, r.Version, r.Description)
+			}
+			return tw.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&versions, "versions", false, "show every version instead of only the latest")
+	return cmd
+}
@@ -32,12 +32,24 @@ func NewRootCommand(application *app.Application) *cobra.Command {
 		NewTemplateCommand(application),
 		NewUpCommand(application),
 		NewDownCommand(application),
+		NewUninstallCommand(application),
+		NewRotateSecretCommand(application),
 		NewLogsCommand(application),
 		NewPSCommand(application),
+		NewImagesCommand(application),
 		NewDiffCommand(application),
 		NewVersionCommand(),
 		NewInitCommand(),
 		NewPackageCommand(application),
+		NewVerifyChartCommand(application),
+		NewGetCommand(application),
+		NewNotesCommand(application),
+		NewExplainValuesCommand(application),
+		NewDependencyCommand(application),
+		NewRepoCommand(application),
+		NewSearchCommand(application),
+		NewPushCommand(application),
+		NewCacheCommand(application),
 	)
 
 	return cmd
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+)
+
+// NewRotateSecretCommand defines `composepack rotate-secret`.
+func NewRotateSecretCommand(application *app.Application) *cobra.Command {
+	var (
+		all        bool
+		runtimeDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rotate-secret <release> [name...]",
+		Short: "Regenerate secrets created by genSecret",
+		Long: `Replace values generated by the genSecret template function with new random values
+of the same length. They take effect the next time the release is rendered, e.g. with
+"composepack up <release>".`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			releaseDir, err := cmd.Flags().GetString("release-dir")
+			if err != nil {
+				return err
+			}
+
+			opts := app.RotateSecretOptions{
+				ReleaseName:    args[0],
+				RuntimeBaseDir: releaseDir,
+				RuntimePath:    runtimeDir,
+				Names:          args[1:],
+				All:            all,
+			}
+
+			rotated, err := application.RotateSecrets(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Rotated %s; run \"composepack up %s\" to apply\n", strings.Join(rotated, ", "), args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "rotate every generated secret of the release")
+	cmd.Flags().StringVar(&runtimeDir, "runtime-dir", "", "path to release directory (overrides --release-dir)")
+
+	return cmd
+}
@@ -9,10 +9,14 @@ import (
 // NewTemplateCommand wires the `composepack template` command skeleton.
 func NewTemplateCommand(application *app.Application) *cobra.Command {
 	var (
-		valueFiles []string
-		setValues  []string
-		chartSrc   string
-		runtimeDir string
+		valFlags     valuesFlags
+		chartSrc     string
+		chartVersion string
+		chartDigest  string
+		pinDigests   bool
+		dlFlags      downloadFlags
+		vfyFlags     verifyFlags
+		runtimeDir   string
 	)
 
 	cmd := &cobra.Command{
@@ -20,13 +24,11 @@ func NewTemplateCommand(application *app.Application) *cobra.Command {
 		Short: "Render a release runtime without invoking docker compose",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			overrides, err := parseSetFlags(setValues)
+			releaseDir, err := cmd.Flags().GetString("release-dir")
 			if err != nil {
 				return err
 			}
-
-			releaseDir, err := cmd.Flags().GetString("release-dir")
-			if err != nil {
+			if err := dlFlags.apply(application.Runtime); err != nil {
 				return err
 			}
 
@@ -34,20 +36,28 @@ func NewTemplateCommand(application *app.Application) *cobra.Command {
 				RenderOptions: app.RenderOptions{
 					ReleaseName:    args[0],
 					ChartSource:    chartSrc,
-					ValueFiles:     append([]string{}, valueFiles...),
-					SetValues:      overrides,
+					ChartVersion:   chartVersion,
+					ChartDigest:    chartDigest,
+					PinDigests:     pinDigests,
 					RuntimeBaseDir: releaseDir,
 					RuntimePath:    runtimeDir,
 				},
 			}
 
+			valFlags.apply(&opts.RenderOptions)
+			vfyFlags.apply(&opts.RenderOptions)
+
 			return application.TemplateRelease(cmd.Context(), opts)
 		},
 	}
 
 	cmd.Flags().StringVar(&chartSrc, "chart", "", "chart directory or archive to render")
-	cmd.Flags().StringArrayVarP(&valueFiles, "values", "f", nil, "values files to include")
-	cmd.Flags().StringArrayVar(&setValues, "set", nil, "direct values to set (key=value)")
+	cmd.Flags().StringVar(&chartVersion, "version", "", "chart version range (e.g. ~1.2) for repository charts such as repo/chart")
+	cmd.Flags().StringVar(&chartDigest, "verify-digest", "", "fail unless the chart archive has this sha256:<hex> digest")
+	cmd.Flags().BoolVar(&pinDigests, "pin-digests", false, "pin every image to its registry digest and record the digests in release.json")
+	valFlags.register(cmd)
+	dlFlags.register(cmd)
+	vfyFlags.register(cmd)
 	cmd.Flags().StringVar(&runtimeDir, "runtime-dir", "", "path to existing release directory (overrides --release-dir)")
 
 	return cmd
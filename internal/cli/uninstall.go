@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+)
+
+// NewUninstallCommand defines `composepack uninstall`.
+func NewUninstallCommand(application *app.Application) *cobra.Command {
+	var (
+		removeVolumes bool
+		noHooks       bool
+		runtimeDir    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "uninstall <release>",
+		Short: "Stop a release and delete its runtime directory",
+		Long: `Run the chart's pre-uninstall hooks, docker compose down, the post-uninstall hooks,
+and then delete the release directory including its history. Use "down" to only stop
+the containers.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			releaseDir, err := cmd.Flags().GetString("release-dir")
+			if err != nil {
+				return err
+			}
+
+			opts := app.UninstallOptions{
+				ReleaseName:    args[0],
+				RuntimeBaseDir: releaseDir,
+				RuntimePath:    runtimeDir,
+				RemoveVolumes:  removeVolumes,
+				NoHooks:        noHooks,
+			}
+
+			return application.UninstallRelease(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&removeVolumes, "volumes", false, "also remove the release's volumes")
+	cmd.Flags().BoolVar(&noHooks, "no-hooks", false, "skip the chart's uninstall hooks")
+	cmd.Flags().StringVar(&runtimeDir, "runtime-dir", "", "path to release directory (overrides --release-dir)")
+
+	return cmd
+}
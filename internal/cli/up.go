@@ -9,11 +9,16 @@ import (
 // NewUpCommand wires the `composepack up` command skeleton.
 func NewUpCommand(application *app.Application) *cobra.Command {
 	var (
-		valueFiles []string
-		setValues  []string
-		chartSrc   string
-		detach     bool
-		runtimeDir string
+		valFlags     valuesFlags
+		chartSrc     string
+		chartVersion string
+		chartDigest  string
+		pinDigests   bool
+		dlFlags      downloadFlags
+		vfyFlags     verifyFlags
+		detach       bool
+		noHooks      bool
+		runtimeDir   string
 	)
 
 	cmd := &cobra.Command{
@@ -21,13 +26,11 @@ func NewUpCommand(application *app.Application) *cobra.Command {
 		Short: "Render and run docker compose up for a release",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			overrides, err := parseSetFlags(setValues)
+			releaseDir, err := cmd.Flags().GetString("release-dir")
 			if err != nil {
 				return err
 			}
-
-			releaseDir, err := cmd.Flags().GetString("release-dir")
-			if err != nil {
+			if err := dlFlags.apply(application.Runtime); err != nil {
 				return err
 			}
 
@@ -35,22 +38,32 @@ func NewUpCommand(application *app.Application) *cobra.Command {
 				RenderOptions: app.RenderOptions{
 					ReleaseName:    args[0],
 					ChartSource:    chartSrc,
-					ValueFiles:     append([]string{}, valueFiles...),
-					SetValues:      overrides,
+					ChartVersion:   chartVersion,
+					ChartDigest:    chartDigest,
+					PinDigests:     pinDigests,
 					RuntimeBaseDir: releaseDir,
 					RuntimePath:    runtimeDir,
 				},
-				Detach: detach,
+				Detach:  detach,
+				NoHooks: noHooks,
 			}
 
+			valFlags.apply(&opts.RenderOptions)
+			vfyFlags.apply(&opts.RenderOptions)
+
 			return application.UpRelease(cmd.Context(), opts)
 		},
 	}
 
 	cmd.Flags().StringVar(&chartSrc, "chart", "", "optional chart directory or archive")
-	cmd.Flags().StringArrayVarP(&valueFiles, "values", "f", nil, "values files to include")
-	cmd.Flags().StringArrayVar(&setValues, "set", nil, "direct values to set")
+	cmd.Flags().StringVar(&chartVersion, "version", "", "chart version range (e.g. ~1.2) for repository charts such as repo/chart")
+	cmd.Flags().StringVar(&chartDigest, "verify-digest", "", "fail unless the chart archive has this sha256:<hex> digest")
+	cmd.Flags().BoolVar(&pinDigests, "pin-digests", false, "pin every image to its registry digest and record the digests in release.json")
+	valFlags.register(cmd)
+	dlFlags.register(cmd)
+	vfyFlags.register(cmd)
 	cmd.Flags().BoolVarP(&detach, "detach", "d", false, "pass --detach to docker compose up")
+	cmd.Flags().BoolVar(&noHooks, "no-hooks", false, "skip the chart's install/upgrade hooks")
 	cmd.Flags().StringVar(&runtimeDir, "runtime-dir", "", "path to existing release directory (overrides --release-dir)")
 
 	return cmd
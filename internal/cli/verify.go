@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"composepack/internal/app"
+)
+
+// NewVerifyChartCommand checks a packaged chart against its provenance file.
+func NewVerifyChartCommand(application *app.Application) *cobra.Command {
+	var (
+		keyring string
+		dlFlags downloadFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify-chart <archive|url>",
+		Short: "Verify the signature and digest of a packaged chart",
+		Long: `Check <archive>.prov (written by package --sign) against the trusted public keys in the
+keyring, and the archive against the digest it signs. For URLs, <url>.prov is downloaded too.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := dlFlags.apply(application.Runtime); err != nil {
+				return err
+			}
+			verification, err := application.VerifyChart(cmd.Context(), args[0], keyring)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Verified %s\n", args[0])
+			fmt.Fprintf(out, "Signed by: %s\n", verification.KeyID)
+			fmt.Fprintf(out, "Digest:    %s\n", verification.Digest)
+			fmt.Fprintf(out, "Signed at: %s\n", verification.Created.Format(time.RFC3339))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&keyring, "keyring", "", "PEM file of trusted Ed25519 public keys (defaults to the configured keyring)")
+	dlFlags.register(cmd)
+	return cmd
+}
@@ -14,8 +14,13 @@ const (
 	TemplatesCompose   = "templates/compose"
 	TemplatesFiles     = "templates/files"
 	TemplatesHelpers   = "templates/helpers"
+	TemplatesHooks     = "templates/hooks"
+	NotesFile          = "templates/NOTES.txt" // rendered and printed after install/up
 	FilesDir           = "files"
+	ChartsDir          = "charts"
 	TemplateFileSuffix = ".tpl"
+	IgnoreFile         = ".cpackignore" // gitignore-style exclusions for packaging and loading
+	ImagesDir          = "images"       // container images bundled by `package --bundle-images`; never extracted
 )
 
 // Loader describes chart loading behavior regardless of source (dir, archive, registry).
@@ -23,6 +28,57 @@ type Loader interface {
 	Load(ctx context.Context, source string) (*Chart, error)
 }
 
+// LoadOptions constrain which chart a source may resolve to.
+type LoadOptions struct {
+	Version  string   // semver range; repository and OCI sources pick the highest match
+	Digest   string   // expected sha256:<hex> of the chart archive
+	Verifier Verifier // when set, the archive's provenance is checked before extraction
+}
+
+// Verifier checks a chart archive against its detached provenance file.
+type Verifier interface {
+	Verify(archive, provenance string) error
+}
+
+// ProvenanceSuffix locates an archive's provenance file (`<archive>.prov`).
+const ProvenanceSuffix = ".prov"
+
+// VersionedLoader is implemented by loaders that can select a chart version from a range,
+// e.g. for repository references such as `myrepo/postgres`, and verify archive digests.
+type VersionedLoader interface {
+	Loader
+	LoadWith(ctx context.Context, source string, opts LoadOptions) (*Chart, error)
+}
+
+// ArchiveResolver is implemented by loaders that can fetch the archive behind a chart source
+// without loading it, resolving it exactly as LoadWith would.
+type ArchiveResolver interface {
+	ResolveArchive(ctx context.Context, source string, opts LoadOptions) (string, func(), error)
+}
+
+// RepositoryResolver maps a `repo/chart` reference and version range to a downloadable archive.
+type RepositoryResolver interface {
+	// ResolveChart returns the archive URL and its expected digest (empty when unknown).
+	// ok is false when the reference does not name a configured repository.
+	ResolveChart(ctx context.Context, ref, version string) (url, digest string, ok bool, err error)
+}
+
+// OCIFetcher downloads charts stored as artifacts in OCI registries (`oci://` sources).
+type OCIFetcher interface {
+	// FetchChart stores the chart archive in a temporary file and returns its path plus a cleanup func.
+	FetchChart(ctx context.Context, source, version string) (string, func(), error)
+}
+
+// ArchiveCache keeps downloaded chart archives addressed by their sha256 digest, so pinned
+// releases re-render from identical bytes without touching the network.
+type ArchiveCache interface {
+	// Open returns the path of the cached archive with the given sha256:<hex> digest.
+	Open(digest string) (string, bool)
+	// Store copies a downloaded archive into the cache, recording where it came from, and
+	// returns its digest.
+	Store(source, path string) (string, error)
+}
+
 // LoaderFunc allows simple function-based implementations of Loader.
 type LoaderFunc func(ctx context.Context, source string) (*Chart, error)
 
@@ -33,22 +89,62 @@ func (f LoaderFunc) Load(ctx context.Context, source string) (*Chart, error) {
 
 // ChartMetadata mirrors Helm-style metadata fields.
 type ChartMetadata struct {
-	Name        string   `yaml:"name"`
-	Version     string   `yaml:"version"`
-	Description string   `yaml:"description,omitempty"`
-	Maintainers []string `yaml:"maintainers,omitempty"`
+	Name         string       `yaml:"name"`
+	Version      string       `yaml:"version"`
+	AppVersion   string       `yaml:"appVersion,omitempty"` // version of the packaged application
+	Description  string       `yaml:"description,omitempty"`
+	Maintainers  []string     `yaml:"maintainers,omitempty"`
+	Env          []EnvVar     `yaml:"env,omitempty"`
+	Dependencies []Dependency `yaml:"dependencies,omitempty"`
+}
+
+// Dependency declares a subchart vendored under charts/.
+type Dependency struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version,omitempty"`    // semver range, e.g. "~1.2"
+	Repository string `yaml:"repository,omitempty"` // file://, http(s):// or repository reference
+	Alias      string `yaml:"alias,omitempty"`      // values key and fragment prefix (defaults to name)
+	Condition  string `yaml:"condition,omitempty"`  // comma-separated value paths; first present one decides
+}
+
+// Key returns the alias used to scope the dependency's values.
+func (d Dependency) Key() string {
+	if d.Alias != "" {
+		return d.Alias
+	}
+	return d.Name
+}
+
+// EnvVar declares an environment variable a chart's templates consume via `.Env` / `env`.
+// Only declared variables are exposed to templates.
+type EnvVar struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description,omitempty"`
+	Required    bool    `yaml:"required,omitempty"`
+	Default     *string `yaml:"default,omitempty"`
+	Secret      bool    `yaml:"secret,omitempty"` // never recorded in release.json
 }
 
 // Chart captures a fully loaded chart from disk/archive.
 type Chart struct {
 	Metadata      ChartMetadata
-	BaseDir       string
+	BaseDir       string // chart directory; empty for charts loaded from an archive
 	Values        map[string]any
 	ValuesSchema  []byte
 	ComposeTpls   map[string]string // templates/compose/*.tpl.yaml (rendered to Compose YAML)
 	FileTemplates map[string]string // templates/files/**/*.tpl (rendered to runtime files)
 	HelperTpls    map[string]string // templates/helpers/**/*.tpl (include-only snippets)
+	HookTpls      map[string]string // templates/hooks/** (hook services and scripts)
+	NotesTpl      string            // templates/NOTES.txt, empty when absent
 	StaticFiles   map[string][]byte // files/**/* (non-templated assets copied verbatim)
+	Dependencies  []*Subchart       // charts/* matched to Chart.yaml dependencies, in declaration order
+	Digest        string            // sha256:<hex> of the archive the chart was loaded from; empty for directories
+}
+
+// Subchart pairs a dependency declaration with the vendored chart that satisfies it.
+type Subchart struct {
+	Dependency Dependency
+	Chart      *Chart
 }
 
 // LoadFromDirectory is a convenience wrapper around the filesystem loader.
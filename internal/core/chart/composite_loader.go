@@ -1,49 +1,222 @@
 package chart
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/Masterminds/semver/v3"
 )
 
 // CompositeLoader delegates to filesystem or archive loader based on source path.
 type CompositeLoader struct {
-	fs *FileSystemChartLoader
+	fs       *FileSystemChartLoader
+	getter   Getter
+	archives ArchiveCache
+	repos    RepositoryResolver
+	oci      OCIFetcher
 }
 
-// NewCompositeLoader builds a loader that supports directories and archives.
-func NewCompositeLoader(fsLoader *FileSystemChartLoader) *CompositeLoader {
-	return &CompositeLoader{fs: fsLoader}
+// NewCompositeLoader builds a loader that supports directories, archives, URLs (through
+// getter) and, when the respective resolvers are non-nil, `repo/chart` references and
+// `oci://` sources. Remote sources pinned to a digest are served from archives when cached.
+func NewCompositeLoader(fsLoader *FileSystemChartLoader, getter Getter, archives ArchiveCache, repos RepositoryResolver, oci OCIFetcher) *CompositeLoader {
+	return &CompositeLoader{fs: fsLoader, getter: getter, archives: archives, repos: repos, oci: oci}
 }
 
 // Load inspects the source and loads from tar/tgz archives or directories.
 func (l *CompositeLoader) Load(ctx context.Context, source string) (*Chart, error) {
+	return l.LoadWith(ctx, source, LoadOptions{})
+}
+
+// LoadWith loads a chart, checks its version against a semver range and, when a digest or
+// verifier is given, checks the archive it came from before extracting it. Repository
+// references pick the highest matching version from the repository index.
+func (l *CompositeLoader) LoadWith(ctx context.Context, source string, opts LoadOptions) (*Chart, error) {
 	if source == "" {
 		return nil, fmt.Errorf("chart source must be provided")
 	}
-	resolved := source
-	cleanup := func() {}
-	if isURL(source) {
-		var err error
-		resolved, cleanup, err = l.downloadIfURL(ctx, source)
+	if opts.Digest != "" {
+		if err := ValidateDigest(opts.Digest); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := l.resolveCached(ctx, source, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer res.cleanup()
+	path := res.path
+
+	if res.indexDigest != "" {
+		if err := VerifyDigest(path, res.indexDigest); err != nil {
+			return nil, fmt.Errorf("chart %s: %w (from the repository index)", source, err)
+		}
+	}
+	if opts.Digest != "" || opts.Verifier != nil {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return nil, fmt.Errorf("chart %s: digests and signatures can only be verified for archives, not directories", source)
+		}
+	}
+	if opts.Digest != "" {
+		if err := VerifyDigest(path, opts.Digest); err != nil {
+			return nil, fmt.Errorf("chart %s: %w", source, err)
+		}
+	}
+	if opts.Verifier != nil {
+		if err := l.verifyProvenance(ctx, res, opts.Verifier); err != nil {
+			return nil, fmt.Errorf("chart %s: %w", source, err)
+		}
+	}
+
+	ch, err := l.loadSource(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if IsArchive(path) {
+		if ch.Digest, err = FileDigest(path); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Version != "" {
+		constraint, err := semver.NewConstraint(opts.Version)
 		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", opts.Version, err)
+		}
+		parsed, err := semver.NewVersion(ch.Metadata.Version)
+		if err != nil || !constraint.Check(parsed) {
+			return nil, fmt.Errorf("chart %s version %s does not satisfy %q", ch.Metadata.Name, ch.Metadata.Version, opts.Version)
+		}
+	}
+	return ch, nil
+}
+
+// ResolveArchive returns the local path of the chart archive behind source, resolved like
+// LoadWith resolves it: opts.Version selects repository and OCI versions and opts.Digest
+// pins (and checks) the exact archive. The caller runs the returned cleanup.
+func (l *CompositeLoader) ResolveArchive(ctx context.Context, source string, opts LoadOptions) (string, func(), error) {
+	if source == "" {
+		return "", nil, fmt.Errorf("chart source must be provided")
+	}
+	res, err := l.resolveCached(ctx, source, LoadOptions{Version: opts.Version, Digest: opts.Digest})
+	if err != nil {
+		return "", nil, err
+	}
+	if info, err := os.Stat(res.path); err != nil || info.IsDir() {
+		res.cleanup()
+		return "", nil, fmt.Errorf("chart %s is not a chart archive", source)
+	}
+	digest := opts.Digest
+	if digest == "" {
+		digest = res.indexDigest
+	}
+	if digest != "" {
+		if err := VerifyDigest(res.path, digest); err != nil {
+			res.cleanup()
+			return "", nil, fmt.Errorf("chart %s: %w", source, err)
+		}
+	}
+	return res.path, res.cleanup, nil
+}
+
+// resolvedSource is a chart source materialised on the local filesystem.
+type resolvedSource struct {
+	path        string
+	indexDigest string // digest published by a repository index, if any
+	origin      string // URL the archive was downloaded from, if any
+	cleanup     func()
+}
+
+// resolveCached serves remote sources pinned to a digest from the archive cache, and
+// otherwise resolves them and stores OCI downloads in the cache. Verified loads always
+// resolve, since the provenance file lives next to the original archive.
+func (l *CompositeLoader) resolveCached(ctx context.Context, source string, opts LoadOptions) (*resolvedSource, error) {
+	remote := IsRemote(source) || (l.repos != nil && IsRepositoryRef(source))
+	if l.archives != nil && remote && opts.Digest != "" && opts.Verifier == nil {
+		if path, ok := l.archives.Open(opts.Digest); ok {
+			return &resolvedSource{path: path, cleanup: func() {}}, nil
+		}
+	}
+
+	res, err := l.resolve(ctx, source, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+	if l.archives != nil && IsOCI(source) {
+		if _, err := l.archives.Store(source, res.path); err != nil {
+			res.cleanup()
 			return nil, err
 		}
-		defer cleanup()
-		source = resolved
 	}
+	return res, nil
+}
 
+// resolve turns repository references, OCI references and URLs into a local path.
+func (l *CompositeLoader) resolve(ctx context.Context, source, version string) (*resolvedSource, error) {
+	switch {
+	case l.repos != nil && IsRepositoryRef(source):
+		url, digest, ok, err := l.repos.ResolveChart(ctx, source, version)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			path, cleanup, err := l.fetch(ctx, url)
+			if err != nil {
+				return nil, err
+			}
+			return &resolvedSource{path: path, indexDigest: digest, origin: url, cleanup: cleanup}, nil
+		}
+	case IsOCI(source):
+		if l.oci == nil {
+			return nil, fmt.Errorf("chart source %q: OCI registries are not configured", source)
+		}
+		path, cleanup, err := l.oci.FetchChart(ctx, source, version)
+		if err != nil {
+			return nil, err
+		}
+		return &resolvedSource{path: path, cleanup: cleanup}, nil
+	case IsURL(source):
+		path, cleanup, err := l.fetch(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		return &resolvedSource{path: path, origin: source, cleanup: cleanup}, nil
+	}
+	return &resolvedSource{path: source, cleanup: func() {}}, nil
+}
+
+// verifyProvenance checks an archive against `<archive>.prov`, downloaded from next to the
+// archive URL for remote charts. OCI fetchers place it next to the downloaded archive.
+func (l *CompositeLoader) verifyProvenance(ctx context.Context, res *resolvedSource, verifier Verifier) error {
+	provenance, cleanup := res.path+ProvenanceSuffix, func() {}
+	if res.origin != "" {
+		var err error
+		provenance, cleanup, err = l.getter.Fetch(ctx, res.origin+ProvenanceSuffix, "composepack-chart-*"+ProvenanceSuffix)
+		if err != nil {
+			return fmt.Errorf("download provenance: %w", err)
+		}
+	}
+	defer cleanup()
+	return verifier.Verify(res.path, provenance)
+}
+
+func (l *CompositeLoader) fetch(ctx context.Context, url string) (string, func(), error) {
+	if l.getter == nil {
+		return "", nil, fmt.Errorf("chart source %q: HTTP downloads are not configured", url)
+	}
+	return Fetch(ctx, l.getter, url)
+}
+
+func (l *CompositeLoader) loadSource(ctx context.Context, source string) (*Chart, error) {
 	if info, err := os.Stat(source); err == nil {
 		if info.IsDir() {
 			return l.fs.Load(ctx, source)
 		}
-		if looksLikeArchive(source) {
+		if IsArchive(source) {
 			return l.loadArchive(ctx, source)
 		}
 		return nil, fmt.Errorf("chart source %q is not a directory", source)
@@ -51,30 +224,71 @@ func (l *CompositeLoader) Load(ctx context.Context, source string) (*Chart, erro
 		return nil, err
 	}
 
-	if looksLikeArchive(source) {
+	if IsArchive(source) {
 		return l.loadArchive(ctx, source)
 	}
 
 	return nil, fmt.Errorf("chart source %q not found", source)
 }
 
-func (l *CompositeLoader) loadArchive(ctx context.Context, source string) (*Chart, error) {
+// IsRemote reports whether source is downloaded (an http(s) URL or an `oci://` reference).
+// Repository references are remote too; see IsRepositoryRef.
+func IsRemote(source string) bool {
+	return IsURL(source) || IsOCI(source)
+}
+
+// IsOCI reports whether source is an `oci://` registry reference.
+func IsOCI(source string) bool {
+	return strings.HasPrefix(strings.ToLower(source), "oci://")
+}
+
+// ReadArchiveMetadata returns the raw Chart.yaml of a packaged chart along with its parsed metadata.
+func ReadArchiveMetadata(path string) ([]byte, *ChartMetadata, error) {
 	tmpDir, err := os.MkdirTemp("", "composepack-chart-*")
 	if err != nil {
-		return nil, fmt.Errorf("create temp dir: %w", err)
+		return nil, nil, fmt.Errorf("create temp dir: %w", err)
 	}
 	defer os.RemoveAll(tmpDir)
-	if err := extractArchive(source, tmpDir); err != nil {
-		return nil, err
+	if err := extractArchive(path, tmpDir); err != nil {
+		return nil, nil, err
 	}
 	root, err := findChartRoot(tmpDir)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(root, MetadataFile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", MetadataFile, err)
+	}
+	meta, err := ParseMetadata(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, meta, nil
+}
+
+// IsRepositoryRef reports whether source looks like a `repo/chart` reference rather than a
+// URL, archive or existing local path.
+func IsRepositoryRef(source string) bool {
+	if IsURL(source) || IsOCI(source) || IsArchive(source) || filepath.IsAbs(source) {
+		return false
+	}
+	repoName, chartName, found := strings.Cut(source, "/")
+	if !found || repoName == "" || chartName == "" || repoName == "." || repoName == ".." || strings.Contains(chartName, "/") {
+		return false
+	}
+	if _, err := os.Stat(source); err == nil {
+		return false
 	}
-	return l.fs.Load(ctx, root)
+	return true
 }
 
-func looksLikeArchive(path string) bool {
+func (l *CompositeLoader) loadArchive(ctx context.Context, source string) (*Chart, error) {
+	return l.fs.loadArchive(ctx, source)
+}
+
+// IsArchive reports whether path names a chart archive (.tgz, .tar, .cpack...).
+func IsArchive(path string) bool {
 	lower := strings.ToLower(path)
 	return strings.HasSuffix(lower, ".tar") ||
 		strings.HasSuffix(lower, ".tar.gz") ||
@@ -83,71 +297,17 @@ func looksLikeArchive(path string) bool {
 		strings.HasSuffix(lower, ".cpack.tgz")
 }
 
-func extractArchive(path, dest string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return fmt.Errorf("open archive: %w", err)
-	}
-	defer file.Close()
-
-	var reader io.Reader = file
-	if strings.HasSuffix(strings.ToLower(path), ".gz") || strings.HasSuffix(strings.ToLower(path), ".tgz") {
-		gz, err := gzip.NewReader(file)
-		if err != nil {
-			return fmt.Errorf("create gzip reader: %w", err)
-		}
-		defer gz.Close()
-		reader = gz
-	}
-
-	tr := tar.NewReader(reader)
-	for {
-		header, err := tr.Next()
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("read archive: %w", err)
-		}
-
-		name := header.Name
-		if shouldSkipArchiveEntry(name) {
-			continue
-		}
-		target := filepath.Join(dest, name)
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
-				return fmt.Errorf("create dir %s: %w", target, err)
-			}
-		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-				return fmt.Errorf("create file dir: %w", err)
-			}
-			out, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(header.Mode))
-			if err != nil {
-				return fmt.Errorf("create file %s: %w", target, err)
-			}
-			if _, err := io.Copy(out, tr); err != nil {
-				out.Close()
-				return fmt.Errorf("write file %s: %w", target, err)
-			}
-			out.Close()
-		}
-	}
-
-	return nil
-}
-
 func shouldSkipArchiveEntry(name string) bool {
 	base := filepath.Base(name)
 	if strings.HasPrefix(base, "._") || base == ".DS_Store" {
 		return true
 	}
-	if strings.HasPrefix(name, "__MACOSX/") {
+	slashed := filepath.ToSlash(name)
+	if strings.HasPrefix(slashed, "__MACOSX/") {
 		return true
 	}
-	return false
+	// Bundled images can be gigabytes; they are streamed from the archive when needed.
+	return slashed == ImagesDir || strings.HasPrefix(slashed, ImagesDir+"/")
 }
 
 func findChartRoot(base string) (string, error) {
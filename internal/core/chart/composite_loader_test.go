@@ -0,0 +1,54 @@
+package chart_test
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/util/fileloader"
+)
+
+// fakeOCI serves one archive for every reference and records the requested versions.
+type fakeOCI struct {
+	archive  string
+	versions []string
+}
+
+func (f *fakeOCI) FetchChart(_ context.Context, _, version string) (string, func(), error) {
+	f.versions = append(f.versions, version)
+	return f.archive, func() {}, nil
+}
+
+func TestResolveArchive(t *testing.T) {
+	archive := writeArchive(t, func(tw *tar.Writer) {
+		addFile(t, tw, "demo/"+chart.MetadataFile, chartYAML)
+	})
+	digest, err := chart.FileDigest(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oci := &fakeOCI{archive: archive}
+	loader := chart.NewCompositeLoader(chart.NewFileSystemChartLoader(fileloader.NewFileSystemLoader()), nil, nil, nil, oci)
+	ctx := context.Background()
+
+	path, cleanup, err := loader.ResolveArchive(ctx, "oci://registry.example/charts/demo", chart.LoadOptions{Version: "0.1.0", Digest: digest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	if path != archive || len(oci.versions) != 1 || oci.versions[0] != "0.1.0" {
+		t.Fatalf("ResolveArchive() = %s after fetching versions %v", path, oci.versions)
+	}
+
+	other := "sha256:" + strings.Repeat("0", 64)
+	if _, _, err := loader.ResolveArchive(ctx, "oci://registry.example/charts/demo", chart.LoadOptions{Digest: other}); !errors.Is(err, chart.ErrDigestMismatch) {
+		t.Fatalf("ResolveArchive() with another digest error = %v, want ErrDigestMismatch", err)
+	}
+
+	if _, _, err := loader.ResolveArchive(ctx, t.TempDir(), chart.LoadOptions{}); err == nil {
+		t.Fatal("ResolveArchive() accepted a chart directory")
+	}
+}
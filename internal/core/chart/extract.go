@@ -0,0 +1,149 @@
+package chart
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Extraction limits for chart archives, which may come from untrusted URLs.
+const (
+	MaxArchiveEntries = 10000     // files and directories
+	MaxArchiveSize    = 256 << 20 // total uncompressed bytes of all files
+)
+
+// ErrUnsafeArchive is matched (via errors.Is) by every ArchiveEntryError.
+var ErrUnsafeArchive = errors.New("unsafe chart archive")
+
+// ArchiveEntryError reports the archive entry that made extraction stop.
+type ArchiveEntryError struct {
+	Archive string // path of the archive being extracted
+	Entry   string // entry name as stored in the archive
+	Reason  string
+}
+
+func (e *ArchiveEntryError) Error() string {
+	return fmt.Sprintf("%s: archive %s: entry %q: %s", ErrUnsafeArchive, e.Archive, e.Entry, e.Reason)
+}
+
+// Unwrap lets callers test for ErrUnsafeArchive.
+func (e *ArchiveEntryError) Unwrap() error { return ErrUnsafeArchive }
+
+// extractArchive unpacks a (gzipped) tar chart archive into dest. Entries must be regular
+// files or directories with relative paths that stay inside dest; links, devices and
+// archives over MaxArchiveEntries / MaxArchiveSize are refused.
+func extractArchive(archivePath, dest string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(strings.ToLower(archivePath), ".gz") || strings.HasSuffix(strings.ToLower(archivePath), ".tgz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("create gzip reader: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var (
+		entries int
+		total   int64
+	)
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+		refuse := func(format string, args ...any) error {
+			return &ArchiveEntryError{Archive: archivePath, Entry: header.Name, Reason: fmt.Sprintf(format, args...)}
+		}
+
+		switch header.Typeflag {
+		case tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeDir, tar.TypeReg:
+		case tar.TypeSymlink, tar.TypeLink:
+			return refuse("links are not allowed in chart archives (target %q)", header.Linkname)
+		default:
+			return refuse("unsupported entry type %q", string(header.Typeflag))
+		}
+
+		name, err := archiveEntryPath(header.Name)
+		if err != nil {
+			return refuse("%v", err)
+		}
+		if shouldSkipArchiveEntry(name) {
+			continue
+		}
+
+		entries++
+		if entries > MaxArchiveEntries {
+			return refuse("archive has more than %d entries", MaxArchiveEntries)
+		}
+
+		target := filepath.Join(dest, name)
+		if header.Typeflag == tar.TypeDir {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return fmt.Errorf("create dir %s: %w", target, err)
+			}
+			continue
+		}
+
+		if header.Size < 0 || total+header.Size > MaxArchiveSize {
+			return refuse("archive expands to more than %d bytes", MaxArchiveSize)
+		}
+		total += header.Size
+		if err := writeArchiveFile(tr, target, header); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archiveEntryPath validates an entry name and converts it to a relative OS path.
+func archiveEntryPath(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	if slashed == "" || strings.HasPrefix(slashed, "/") || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("absolute paths are not allowed")
+	}
+	cleaned := path.Clean(slashed)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("path escapes the archive root")
+	}
+	local := filepath.FromSlash(cleaned)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("path is not a plain relative path")
+	}
+	return local, nil
+}
+
+func writeArchiveFile(tr *tar.Reader, target string, header *tar.Header) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("create file dir: %w", err)
+	}
+	// Only permission bits are honoured; setuid/setgid/sticky bits are dropped.
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm()|0o600)
+	if err != nil {
+		return fmt.Errorf("create file %s: %w", target, err)
+	}
+	if _, err := io.Copy(out, tr); err != nil {
+		out.Close()
+		return fmt.Errorf("write file %s: %w", target, err)
+	}
+	return out.Close()
+}
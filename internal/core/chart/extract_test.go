@@ -0,0 +1,136 @@
+package chart_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"composepack/internal/core/chart"
+)
+
+const chartYAML = "apiVersion: v2\nname: demo\nversion: 0.1.0\n"
+
+// writeArchive gzips the tar produced by build into a .cpack.tgz file and returns its path.
+func writeArchive(t *testing.T, build func(tw *tar.Writer)) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	build(tw)
+	// Errors are ignored: crafted archives may end with a header whose content is missing.
+	_ = tw.Close()
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "demo-0.1.0.cpack.tgz")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func addFile(t *testing.T, tw *tar.Writer, name, content string) {
+	t.Helper()
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadArchiveMetadata(t *testing.T) {
+	archive := writeArchive(t, func(tw *tar.Writer) {
+		if err := tw.WriteHeader(&tar.Header{Name: "demo/", Mode: 0o755, Typeflag: tar.TypeDir}); err != nil {
+			t.Fatal(err)
+		}
+		addFile(t, tw, "demo/"+chart.MetadataFile, chartYAML)
+		addFile(t, tw, "demo/templates/compose/web.yaml", "services: {}\n")
+	})
+	data, meta, err := chart.ReadArchiveMetadata(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != chartYAML || meta.Name != "demo" || meta.Version != "0.1.0" {
+		t.Fatalf("ReadArchiveMetadata() = %q, %+v", data, meta)
+	}
+}
+
+func TestExtractRefusesUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		entry  string
+		header tar.Header
+		reason string
+	}{
+		{"parent directory", "../evil.sh", tar.Header{Name: "../evil.sh", Typeflag: tar.TypeReg}, "escapes the archive root"},
+		{"nested parent directory", "demo/../../evil.sh", tar.Header{Name: "demo/../../evil.sh", Typeflag: tar.TypeReg}, "escapes the archive root"},
+		{"absolute path", "/etc/cron.d/evil", tar.Header{Name: "/etc/cron.d/evil", Typeflag: tar.TypeReg}, "absolute paths"},
+		{"symlink", "demo/templates", tar.Header{Name: "demo/templates", Linkname: "/etc", Typeflag: tar.TypeSymlink}, "links are not allowed"},
+		{"hardlink", "demo/passwd", tar.Header{Name: "demo/passwd", Linkname: "/etc/passwd", Typeflag: tar.TypeLink}, "links are not allowed"},
+		{"device", "demo/null", tar.Header{Name: "demo/null", Typeflag: tar.TypeChar}, "unsupported entry type"},
+		{"oversized file", "demo/huge.bin", tar.Header{Name: "demo/huge.bin", Size: chart.MaxArchiveSize + 1, Typeflag: tar.TypeReg}, "expands to more than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := writeArchive(t, func(tw *tar.Writer) {
+				addFile(t, tw, "demo/"+chart.MetadataFile, chartYAML)
+				header := tt.header
+				header.Mode = 0o644
+				if err := tw.WriteHeader(&header); err != nil {
+					t.Fatal(err)
+				}
+			})
+			assertEntryError(t, archive, tt.entry, tt.reason)
+		})
+	}
+}
+
+func TestExtractRefusesOversizedTotal(t *testing.T) {
+	// Two files that fit on their own but not together; only headers are checked before reading.
+	half := int64(chart.MaxArchiveSize/2 + 1)
+	archive := writeArchive(t, func(tw *tar.Writer) {
+		if err := tw.WriteHeader(&tar.Header{Name: "demo/a.bin", Mode: 0o644, Size: half, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(make([]byte, half)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: "demo/b.bin", Mode: 0o644, Size: half, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assertEntryError(t, archive, "demo/b.bin", "expands to more than")
+}
+
+func TestExtractRefusesTooManyEntries(t *testing.T) {
+	archive := writeArchive(t, func(tw *tar.Writer) {
+		for i := 0; i <= chart.MaxArchiveEntries; i++ {
+			if err := tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("demo/d%05d/", i), Mode: 0o755, Typeflag: tar.TypeDir}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	assertEntryError(t, archive, fmt.Sprintf("demo/d%05d/", chart.MaxArchiveEntries), "more than")
+}
+
+func assertEntryError(t *testing.T, archive, entry, reason string) {
+	t.Helper()
+	_, _, err := chart.ReadArchiveMetadata(archive)
+	var entryErr *chart.ArchiveEntryError
+	if !errors.As(err, &entryErr) {
+		t.Fatalf("ReadArchiveMetadata() error = %v, want an *ArchiveEntryError", err)
+	}
+	if !errors.Is(err, chart.ErrUnsafeArchive) {
+		t.Fatalf("error %v does not match ErrUnsafeArchive", err)
+	}
+	if entryErr.Entry != entry || entryErr.Archive != archive || !strings.Contains(entryErr.Reason, reason) {
+		t.Fatalf("error = %+v, want entry %q with a reason containing %q", entryErr, entry, reason)
+	}
+}
@@ -2,57 +2,31 @@ package chart
 
 import (
 	"context"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"strings"
 )
 
-func (l *CompositeLoader) downloadIfURL(ctx context.Context, source string) (string, func(), error) {
-	if !isURL(source) {
-		return source, func() {}, nil
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
-	if err != nil {
-		return "", nil, fmt.Errorf("build request: %w", err)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", nil, fmt.Errorf("download chart: %w", err)
-	}
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return "", nil, fmt.Errorf("download chart: unexpected status %s", resp.Status)
-	}
-	defer resp.Body.Close()
-
-	tmpFile, err := os.CreateTemp("", "composepack-chart-*.cpack.tgz")
-	if err != nil {
-		return "", nil, fmt.Errorf("create temp file: %w", err)
-	}
+// Getter downloads http(s) chart sources.
+type Getter interface {
+	// Fetch stores the content of url in a local file and returns its path plus a cleanup
+	// func. pattern (see os.CreateTemp) names temporary files; caching getters may return a
+	// shared read-only file instead.
+	Fetch(ctx context.Context, url, pattern string) (string, func(), error)
+}
 
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-		return "", nil, fmt.Errorf("save downloaded chart: %w", err)
-	}
+// archivePattern names downloaded chart archives so they are recognised by IsArchive.
+const archivePattern = "composepack-chart-*.cpack.tgz"
 
-	if err := tmpFile.Close(); err != nil {
-		os.Remove(tmpFile.Name())
-		return "", nil, err
-	}
-
-	cleanup := func() {
-		os.Remove(tmpFile.Name())
+// Fetch downloads http(s) chart sources through getter to a temporary archive and returns
+// its path plus a cleanup func. Local sources are returned unchanged with a no-op cleanup.
+func Fetch(ctx context.Context, getter Getter, source string) (string, func(), error) {
+	if !IsURL(source) {
+		return source, func() {}, nil
 	}
-
-	return tmpFile.Name(), cleanup, nil
+	return getter.Fetch(ctx, source, archivePattern)
 }
 
-func isURL(source string) bool {
+// IsURL reports whether source is an http(s) URL.
+func IsURL(source string) bool {
 	lower := strings.ToLower(source)
 	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
 }
@@ -2,13 +2,18 @@ package chart
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"sigs.k8s.io/yaml"
 
 	"composepack/internal/util/fileloader"
+	"composepack/internal/util/ignore"
 )
 
 // FileSystemChartLoader loads charts from directories on disk.
@@ -36,6 +41,7 @@ func (l *FileSystemChartLoader) Load(ctx context.Context, source string) (*Chart
 		ComposeTpls:   map[string]string{},
 		FileTemplates: map[string]string{},
 		HelperTpls:    map[string]string{},
+		HookTpls:      map[string]string{},
 		StaticFiles:   map[string][]byte{},
 	}
 
@@ -51,19 +57,36 @@ func (l *FileSystemChartLoader) Load(ctx context.Context, source string) (*Chart
 		return nil, err
 	}
 
-	if err := l.loadComposeTemplates(ctx, ch); err != nil {
+	rules, err := LoadIgnoreRules(ch.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.loadComposeTemplates(ctx, ch, rules); err != nil {
 		return nil, err
 	}
 
-	if err := l.loadFileTemplates(ctx, ch); err != nil {
+	if err := l.loadFileTemplates(ctx, ch, rules); err != nil {
 		return nil, err
 	}
 
-	if err := l.loadHelperTemplates(ctx, ch); err != nil {
+	if err := l.loadHelperTemplates(ctx, ch, rules); err != nil {
 		return nil, err
 	}
 
-	if err := l.loadStaticFiles(ctx, ch); err != nil {
+	if err := l.loadHookTemplates(ctx, ch, rules); err != nil {
+		return nil, err
+	}
+
+	if err := l.loadNotes(ch, rules); err != nil {
+		return nil, err
+	}
+
+	if err := l.loadStaticFiles(ctx, ch, rules); err != nil {
+		return nil, err
+	}
+
+	if err := l.loadDependencies(ctx, ch); err != nil {
 		return nil, err
 	}
 
@@ -77,16 +100,45 @@ func (l *FileSystemChartLoader) loadMetadata(ch *Chart) error {
 		return fmt.Errorf("read %s: %w", MetadataFile, err)
 	}
 
+	meta, err := ParseMetadata(data)
+	if err != nil {
+		return err
+	}
+	ch.Metadata = *meta
+	return nil
+}
+
+// ParseMetadata decodes and validates Chart.yaml contents.
+func ParseMetadata(data []byte) (*ChartMetadata, error) {
 	var meta ChartMetadata
 	if err := yaml.Unmarshal(data, &meta); err != nil {
-		return fmt.Errorf("parse %s: %w", MetadataFile, err)
+		return nil, fmt.Errorf("parse %s: %w", MetadataFile, err)
 	}
 	if meta.Name == "" || meta.Version == "" {
-		return fmt.Errorf("chart metadata must include name and version")
+		return nil, fmt.Errorf("chart metadata must include name and version")
+	}
+	keys := map[string]bool{}
+	for _, dep := range meta.Dependencies {
+		if dep.Name == "" {
+			return nil, fmt.Errorf("%s: dependencies must include a name", MetadataFile)
+		}
+		if keys[dep.Key()] {
+			return nil, fmt.Errorf("%s: dependency %s declared more than once (use alias to include a chart twice)", MetadataFile, dep.Key())
+		}
+		keys[dep.Key()] = true
+	}
+	seen := map[string]bool{}
+	for _, env := range meta.Env {
+		if env.Name == "" {
+			return nil, fmt.Errorf("%s: env entries must include a name", MetadataFile)
+		}
+		if seen[env.Name] {
+			return nil, fmt.Errorf("%s: env %s declared more than once", MetadataFile, env.Name)
+		}
+		seen[env.Name] = true
 	}
 
-	ch.Metadata = meta
-	return nil
+	return &meta, nil
 }
 
 func (l *FileSystemChartLoader) loadValues(ch *Chart) error {
@@ -117,17 +169,15 @@ func (l *FileSystemChartLoader) loadSchema(ch *Chart) error {
 	return nil
 }
 
-func (l *FileSystemChartLoader) loadComposeTemplates(ctx context.Context, ch *Chart) error {
-	dir := filepath.Join(ch.BaseDir, TemplatesCompose)
-	return l.files.WalkFiles(ctx, dir, func(rel string, data []byte) error {
+func (l *FileSystemChartLoader) loadComposeTemplates(ctx context.Context, ch *Chart, rules *ignore.Rules) error {
+	return l.walk(ctx, ch, rules, TemplatesCompose, func(rel string, data []byte) error {
 		ch.ComposeTpls[rel] = string(data)
 		return nil
 	})
 }
 
-func (l *FileSystemChartLoader) loadFileTemplates(ctx context.Context, ch *Chart) error {
-	dir := filepath.Join(ch.BaseDir, TemplatesFiles)
-	return l.files.WalkFiles(ctx, dir, func(rel string, data []byte) error {
+func (l *FileSystemChartLoader) loadFileTemplates(ctx context.Context, ch *Chart, rules *ignore.Rules) error {
+	return l.walk(ctx, ch, rules, TemplatesFiles, func(rel string, data []byte) error {
 		if !strings.HasSuffix(rel, TemplateFileSuffix) {
 			return fmt.Errorf("file template %s must end with %s", rel, TemplateFileSuffix)
 		}
@@ -137,18 +187,254 @@ func (l *FileSystemChartLoader) loadFileTemplates(ctx context.Context, ch *Chart
 	})
 }
 
-func (l *FileSystemChartLoader) loadHelperTemplates(ctx context.Context, ch *Chart) error {
-	dir := filepath.Join(ch.BaseDir, TemplatesHelpers)
-	return l.files.WalkFiles(ctx, dir, func(rel string, data []byte) error {
+func (l *FileSystemChartLoader) loadHelperTemplates(ctx context.Context, ch *Chart, rules *ignore.Rules) error {
+	return l.walk(ctx, ch, rules, TemplatesHelpers, func(rel string, data []byte) error {
 		ch.HelperTpls[rel] = string(data)
 		return nil
 	})
 }
 
-func (l *FileSystemChartLoader) loadStaticFiles(ctx context.Context, ch *Chart) error {
-	dir := filepath.Join(ch.BaseDir, FilesDir)
-	return l.files.WalkFiles(ctx, dir, func(rel string, data []byte) error {
+func (l *FileSystemChartLoader) loadHookTemplates(ctx context.Context, ch *Chart, rules *ignore.Rules) error {
+	return l.walk(ctx, ch, rules, TemplatesHooks, func(rel string, data []byte) error {
+		ch.HookTpls[rel] = string(data)
+		return nil
+	})
+}
+
+func (l *FileSystemChartLoader) loadNotes(ch *Chart, rules *ignore.Rules) error {
+	if rules.Ignored(NotesFile, false) {
+		return nil
+	}
+	data, err := l.files.ReadFileIfExists(filepath.Join(ch.BaseDir, filepath.FromSlash(NotesFile)))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", NotesFile, err)
+	}
+	ch.NotesTpl = string(data)
+	return nil
+}
+
+func (l *FileSystemChartLoader) loadStaticFiles(ctx context.Context, ch *Chart, rules *ignore.Rules) error {
+	return l.walk(ctx, ch, rules, FilesDir, func(rel string, data []byte) error {
 		ch.StaticFiles[rel] = data
 		return nil
 	})
 }
+
+// walk visits the files below a chart subdirectory that .cpackignore does not exclude.
+func (l *FileSystemChartLoader) walk(ctx context.Context, ch *Chart, rules *ignore.Rules, subdir string, visit func(rel string, data []byte) error) error {
+	dir := filepath.Join(ch.BaseDir, subdir)
+	return l.files.WalkFilesExcept(ctx, dir, func(rel string, isDir bool) bool {
+		return rules.Ignored(subdir+"/"+rel, isDir)
+	}, visit)
+}
+
+// LoadIgnoreRules reads the .cpackignore file of a chart directory (empty rules when absent).
+func LoadIgnoreRules(chartDir string) (*ignore.Rules, error) {
+	return ignore.Load(filepath.Join(chartDir, IgnoreFile))
+}
+
+// loadDependencies loads every chart vendored under charts/ (directories or archives)
+// and matches them to the dependencies declared in Chart.yaml.
+func (l *FileSystemChartLoader) loadDependencies(ctx context.Context, ch *Chart) error {
+	if len(ch.Metadata.Dependencies) == 0 {
+		return nil
+	}
+
+	vendored, unreadable, err := l.loadVendoredCharts(ctx, filepath.Join(ch.BaseDir, ChartsDir), ch.Metadata.Dependencies)
+	if err != nil {
+		return err
+	}
+
+	for _, dep := range ch.Metadata.Dependencies {
+		match, err := matchDependency(dep, vendored)
+		if err != nil && len(unreadable) > 0 {
+			err = fmt.Errorf("%w; unreadable entries: %w", err, errors.Join(unreadable...))
+		}
+		if err != nil {
+			return fmt.Errorf("chart %s: %w", ch.Metadata.Name, err)
+		}
+		ch.Dependencies = append(ch.Dependencies, &Subchart{Dependency: dep, Chart: match})
+	}
+	return nil
+}
+
+// loadVendoredCharts loads the charts vendored under dir (directories or archives) that can
+// satisfy one of deps. Other entries, such as leftovers of older versions, are returned with
+// their metadata only, so a broken one cannot fail the parent chart. Entries without a
+// readable Chart.yaml are returned as unreadable, to explain a dependency left unmatched.
+func (l *FileSystemChartLoader) loadVendoredCharts(ctx context.Context, dir string, deps []Dependency) ([]*Chart, []error, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("read %s: %w", ChartsDir, err)
+	}
+
+	var (
+		charts     []*Chart
+		unreadable []error
+	)
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		path := filepath.Join(dir, entry.Name())
+		if shouldSkipArchiveEntry(entry.Name()) {
+			continue
+		}
+
+		var (
+			sub  *Chart
+			read bool
+		)
+		switch {
+		case entry.IsDir():
+			sub, read, err = l.loadCandidate(ctx, path, deps)
+		case IsArchive(path):
+			sub, read, err = l.loadCandidateArchive(ctx, path, deps)
+		default:
+			continue
+		}
+		rel := filepath.Join(ChartsDir, entry.Name())
+		if err != nil && !read {
+			unreadable = append(unreadable, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("load subchart %s: %w", rel, err)
+		}
+		charts = append(charts, sub)
+	}
+	return charts, unreadable, nil
+}
+
+// loadCandidate loads the chart in root when it can satisfy one of deps and only reads its
+// metadata otherwise. read reports whether the metadata could be read.
+func (l *FileSystemChartLoader) loadCandidate(ctx context.Context, root string, deps []Dependency) (*Chart, bool, error) {
+	data, err := l.files.ReadFile(filepath.Join(root, MetadataFile))
+	if err != nil {
+		return nil, false, fmt.Errorf("read %s: %w", MetadataFile, err)
+	}
+	meta, err := ParseMetadata(data)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, dep := range deps {
+		if dependencyAccepts(dep, meta) {
+			ch, err := l.Load(ctx, root)
+			return ch, true, err
+		}
+	}
+	return &Chart{Metadata: *meta}, true, nil
+}
+
+// loadCandidateArchive is loadCandidate for a vendored archive.
+func (l *FileSystemChartLoader) loadCandidateArchive(ctx context.Context, path string, deps []Dependency) (*Chart, bool, error) {
+	tmpDir, err := os.MkdirTemp("", "composepack-subchart-*")
+	if err != nil {
+		return nil, true, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := extractArchive(path, tmpDir); err != nil {
+		return nil, false, err
+	}
+	root, err := findChartRoot(tmpDir)
+	if err != nil {
+		return nil, false, err
+	}
+	ch, read, err := l.loadCandidate(ctx, root, deps)
+	if ch != nil {
+		ch.BaseDir = "" // removed with tmpDir
+	}
+	return ch, read, err
+}
+
+// loadArchive loads a packaged chart. Its files are extracted to a temporary directory that
+// is gone once the chart is loaded, so the chart has no BaseDir.
+func (l *FileSystemChartLoader) loadArchive(ctx context.Context, path string) (*Chart, error) {
+	tmpDir, err := os.MkdirTemp("", "composepack-subchart-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := extractArchive(path, tmpDir); err != nil {
+		return nil, err
+	}
+	root, err := findChartRoot(tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := l.Load(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	ch.BaseDir = ""
+	return ch, nil
+}
+
+// dependencyAccepts reports whether a chart could satisfy dep, following matchDependency:
+// the name must match and, when dep has a valid range, the version must be semver within it.
+func dependencyAccepts(dep Dependency, meta *ChartMetadata) bool {
+	if meta.Name != dep.Name {
+		return false
+	}
+	if dep.Version == "" {
+		return true
+	}
+	constraint, err := semver.NewConstraint(dep.Version)
+	if err != nil {
+		return true // matchDependency reports the invalid range
+	}
+	version, err := semver.NewVersion(meta.Version)
+	return err == nil && constraint.Check(version)
+}
+
+// matchDependency picks the vendored chart with the dependency's name whose version satisfies
+// the declared range, preferring the highest version.
+func matchDependency(dep Dependency, vendored []*Chart) (*Chart, error) {
+	var constraint *semver.Constraints
+	if dep.Version != "" {
+		c, err := semver.NewConstraint(dep.Version)
+		if err != nil {
+			return nil, fmt.Errorf("dependency %s: invalid version range %q: %w", dep.Name, dep.Version, err)
+		}
+		constraint = c
+	}
+
+	var (
+		best        *Chart
+		bestVersion *semver.Version
+		found       []string
+	)
+	for _, candidate := range vendored {
+		if candidate.Metadata.Name != dep.Name {
+			continue
+		}
+		found = append(found, candidate.Metadata.Version)
+		version, err := semver.NewVersion(candidate.Metadata.Version)
+		if err != nil {
+			if constraint != nil {
+				continue
+			}
+			if best == nil {
+				best = candidate
+			}
+			continue
+		}
+		if constraint != nil && !constraint.Check(version) {
+			continue
+		}
+		if bestVersion == nil || version.GreaterThan(bestVersion) {
+			best, bestVersion = candidate, version
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+	if len(found) > 0 {
+		return nil, fmt.Errorf("dependency %s: vendored versions %s do not satisfy %q", dep.Name, strings.Join(found, ", "), dep.Version)
+	}
+	return nil, fmt.Errorf("dependency %s is not vendored under %s/", dep.Name, ChartsDir)
+}
@@ -0,0 +1,130 @@
+package chart
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// LockFile records the exact dependency versions resolved by `composepack dependency update`.
+const LockFile = "Chart.lock"
+
+// Lock is the on-disk representation of Chart.lock.
+type Lock struct {
+	Generated    time.Time          `json:"generated"`
+	Digest       string             `json:"digest"` // digest of the Chart.yaml dependencies the lock was resolved from
+	Dependencies []LockedDependency `json:"dependencies"`
+}
+
+// LockedDependency pins one dependency to an exact version and archive digest.
+type LockedDependency struct {
+	Name       string `json:"name"`
+	Alias      string `json:"alias,omitempty"`
+	Version    string `json:"version"`
+	Repository string `json:"repository"`
+	Digest     string `json:"digest"` // sha256:<hex> of the archive vendored under charts/
+}
+
+// Key returns the alias used to scope the dependency's values.
+func (d LockedDependency) Key() string {
+	if d.Alias != "" {
+		return d.Alias
+	}
+	return d.Name
+}
+
+// Find returns the locked entry for a dependency key.
+func (l *Lock) Find(key string) (LockedDependency, bool) {
+	for _, dep := range l.Dependencies {
+		if dep.Key() == key {
+			return dep, true
+		}
+	}
+	return LockedDependency{}, false
+}
+
+// DependenciesDigest fingerprints dependency declarations so stale locks can be detected.
+func DependenciesDigest(deps []Dependency) string {
+	data, _ := json.Marshal(deps)
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// FileDigest returns the sha256:<hex> digest of a file, as recorded in Chart.lock and index.yaml.
+func FileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ErrDigestMismatch is returned when an archive does not have the expected digest.
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+// ValidateDigest checks that digest has the sha256:<hex> form produced by FileDigest.
+func ValidateDigest(digest string) error {
+	hexPart, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok || len(hexPart) != sha256.Size*2 {
+		return fmt.Errorf("invalid digest %q (expected sha256:<64 hex characters>)", digest)
+	}
+	if _, err := hex.DecodeString(hexPart); err != nil {
+		return fmt.Errorf("invalid digest %q (expected sha256:<64 hex characters>)", digest)
+	}
+	return nil
+}
+
+// VerifyDigest compares a file's digest with the expected sha256:<hex> value.
+func VerifyDigest(path, want string) error {
+	got, err := FileDigest(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("%w (expected %s, got %s)", ErrDigestMismatch, want, got)
+	}
+	return nil
+}
+
+// LoadLock reads Chart.lock from a chart directory. A missing lock returns nil without error.
+func LoadLock(chartDir string) (*Lock, error) {
+	data, err := os.ReadFile(filepath.Join(chartDir, LockFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", LockFile, err)
+	}
+	var lock Lock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", LockFile, err)
+	}
+	return &lock, nil
+}
+
+// WriteLock writes Chart.lock into a chart directory.
+func WriteLock(chartDir string, lock *Lock) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", LockFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, LockFile), data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", LockFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+// Package chartcache stores downloaded chart archives under the user cache directory,
+// addressed by sha256 digest and indexed by the URL or reference they were fetched from.
+package chartcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/infra/config"
+	"composepack/internal/infra/download"
+	"composepack/internal/util/fsutil"
+)
+
+const (
+	blobsDir   = "blobs"
+	blobSuffix = ".tgz" // chart archives are gzipped tarballs; the suffix keeps chart.IsArchive happy
+	refsFile   = "refs.yaml"
+)
+
+// Ref records which archive a source (URL or chart reference) last resolved to, with the
+// HTTP validators used to revalidate it.
+type Ref struct {
+	Source       string    `json:"source"`
+	Digest       string    `json:"digest"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+type refs struct {
+	Refs []*Ref `json:"refs"`
+}
+
+// Blob is one cached archive and the sources that resolved to it.
+type Blob struct {
+	Digest   string
+	Size     int64
+	LastUsed time.Time
+	Sources  []string
+}
+
+// Cache implements chart.Getter on top of a download client and chart.ArchiveCache.
+type Cache struct {
+	Dir  string
+	HTTP *download.Client
+
+	mu sync.Mutex
+}
+
+// NewCache builds a cache rooted at the configured chart cache directory.
+func NewCache(cfg config.Config, http *download.Client) *Cache {
+	return &Cache{Dir: cfg.ChartCache, HTTP: http}
+}
+
+// Fetch implements chart.Getter. A cached archive for url is revalidated with its ETag or
+// Last-Modified date and returned without downloading when unchanged. In offline mode the
+// cached archive is used as-is. The returned path points into the cache and must not be
+// modified.
+func (c *Cache) Fetch(ctx context.Context, url, pattern string) (string, func(), error) {
+	noop := func() {}
+	ref, err := c.lookup(url)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if c.HTTP.Offline {
+		if ref == nil {
+			return "", nil, fmt.Errorf("%s is not in the chart cache (offline mode)", url)
+		}
+		return c.use(ref.Digest), noop, nil
+	}
+
+	var validators download.Validators
+	if ref != nil {
+		validators = download.Validators{ETag: ref.ETag, LastModified: ref.LastModified}
+	}
+	tmp, cleanup, resp, err := c.HTTP.FetchIfModified(ctx, url, pattern, validators)
+	if err != nil {
+		return "", nil, err
+	}
+	defer cleanup()
+
+	if resp.NotModified {
+		ref.Fetched = time.Now().UTC()
+		if err := c.record(ref); err != nil {
+			return "", nil, err
+		}
+		return c.use(ref.Digest), noop, nil
+	}
+
+	digest, err := c.put(tmp)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := c.record(&Ref{
+		Source:       url,
+		Digest:       digest,
+		ETag:         resp.ETag,
+		LastModified: resp.LastModified,
+		Fetched:      time.Now().UTC(),
+	}); err != nil {
+		return "", nil, err
+	}
+	return c.blobPath(digest), noop, nil
+}
+
+// Open implements chart.ArchiveCache.
+func (c *Cache) Open(digest string) (string, bool) {
+	if chart.ValidateDigest(digest) != nil {
+		return "", false
+	}
+	if _, err := os.Stat(c.blobPath(digest)); err != nil {
+		return "", false
+	}
+	return c.use(digest), true
+}
+
+// Store implements chart.ArchiveCache.
+func (c *Cache) Store(source, path string) (string, error) {
+	digest, err := c.put(path)
+	if err != nil {
+		return "", err
+	}
+	return digest, c.record(&Ref{Source: source, Digest: digest, Fetched: time.Now().UTC()})
+}
+
+// List returns every cached archive, most recently used first.
+func (c *Cache) List() ([]*Blob, error) {
+	entries, err := os.ReadDir(filepath.Join(c.Dir, blobsDir, "sha256"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read chart cache: %w", err)
+	}
+	current, err := c.loadRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []*Blob
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, blobSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		blob := &Blob{
+			Digest:   "sha256:" + strings.TrimSuffix(name, blobSuffix),
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+		}
+		for _, ref := range current.Refs {
+			if ref.Digest == blob.Digest {
+				blob.Sources = append(blob.Sources, ref.Source)
+			}
+		}
+		blobs = append(blobs, blob)
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].LastUsed.After(blobs[j].LastUsed) })
+	return blobs, nil
+}
+
+// Clean removes archives not used within maxAge (every archive when maxAge is zero) and
+// forgets the sources that pointed at them.
+func (c *Cache) Clean(ctx context.Context, maxAge time.Duration) ([]*Blob, error) {
+	blobs, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := time.Now().Add(-maxAge)
+	var removed []*Blob
+	for _, blob := range blobs {
+		if maxAge > 0 && blob.LastUsed.After(cutoff) {
+			continue
+		}
+		if err := os.Remove(c.blobPath(blob.Digest)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, fmt.Errorf("remove %s: %w", blob.Digest, err)
+		}
+		removed = append(removed, blob)
+	}
+
+	current, err := c.loadRefs()
+	if err != nil {
+		return removed, err
+	}
+	kept := current.Refs[:0]
+	for _, ref := range current.Refs {
+		if _, err := os.Stat(c.blobPath(ref.Digest)); err == nil {
+			kept = append(kept, ref)
+		}
+	}
+	current.Refs = kept
+	return removed, c.saveRefs(ctx, current)
+}
+
+// lookup returns the ref for source when its archive is still cached.
+func (c *Cache) lookup(source string) (*Ref, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current, err := c.loadRefs()
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range current.Refs {
+		if ref.Source != source {
+			continue
+		}
+		if _, err := os.Stat(c.blobPath(ref.Digest)); err != nil {
+			return nil, nil
+		}
+		return ref, nil
+	}
+	return nil, nil
+}
+
+// record replaces the ref for its source.
+func (c *Cache) record(ref *Ref) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current, err := c.loadRefs()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, existing := range current.Refs {
+		if existing.Source == ref.Source {
+			current.Refs[i], replaced = ref, true
+		}
+	}
+	if !replaced {
+		current.Refs = append(current.Refs, ref)
+	}
+	sort.Slice(current.Refs, func(i, j int) bool { return current.Refs[i].Source < current.Refs[j].Source })
+	return c.saveRefs(context.Background(), current)
+}
+
+// put copies an archive into the blob store unless it is already present.
+func (c *Cache) put(path string) (string, error) {
+	digest, err := chart.FileDigest(path)
+	if err != nil {
+		return "", err
+	}
+	target := c.blobPath(digest)
+	if _, err := os.Stat(target); err == nil {
+		return digest, nil
+	}
+	if err := fsutil.EnsureDir(filepath.Dir(target)); err != nil {
+		return "", fmt.Errorf("create chart cache: %w", err)
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("create chart cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("write chart cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("write chart cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", fmt.Errorf("write chart cache entry: %w", err)
+	}
+	return digest, nil
+}
+
+// use marks a blob as recently used (for Clean) and returns its path.
+func (c *Cache) use(digest string) string {
+	path := c.blobPath(digest)
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return path
+}
+
+func (c *Cache) blobPath(digest string) string {
+	algorithm, hexPart, _ := strings.Cut(digest, ":")
+	return filepath.Join(c.Dir, blobsDir, algorithm, hexPart+blobSuffix)
+}
+
+func (c *Cache) loadRefs() (*refs, error) {
+	current := &refs{}
+	data, err := os.ReadFile(filepath.Join(c.Dir, refsFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return current, nil
+		}
+		return nil, fmt.Errorf("read chart cache: %w", err)
+	}
+	if err := yaml.Unmarshal(data, current); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Join(c.Dir, refsFile), err)
+	}
+	return current, nil
+}
+
+func (c *Cache) saveRefs(ctx context.Context, current *refs) error {
+	data, err := yaml.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("encode chart cache refs: %w", err)
+	}
+	return fsutil.WriteFileAtomic(ctx, filepath.Join(c.Dir, refsFile), data, 0o644)
+}
@@ -0,0 +1,14 @@
+package chartcache
+
+import (
+	"github.com/google/wire"
+
+	"composepack/internal/core/chart"
+)
+
+// ProviderSet exposes the chart cache as the loader's getter and archive cache.
+var ProviderSet = wire.NewSet(
+	NewCache,
+	wire.Bind(new(chart.Getter), new(*Cache)),
+	wire.Bind(new(chart.ArchiveCache), new(*Cache)),
+)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"composepack/internal/infra/process"
@@ -38,12 +39,17 @@ type MergeOptions struct {
 	WorkingDir    string
 	FragmentPaths []string
 	ProjectName   string
+	// ResolveImageDigests asks compose to pin every image to its registry digest.
+	ResolveImageDigests bool
 }
 
 // CommandOptions describe docker compose command invocations from runtime directories.
 type CommandOptions struct {
 	WorkingDir string
 	Args       []string
+	// Stdout and Stderr, when set, stream the command output (see process.Command).
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 // MergeFragments shells out to `docker compose config` to get a merged YAML.
@@ -57,8 +63,11 @@ func (r *Runner) MergeFragments(ctx context.Context, opts MergeOptions) ([]byte,
 		args = append(args, "-f", path)
 	}
 	args = append(args, "config")
+	if opts.ResolveImageDigests {
+		args = append(args, "--resolve-image-digests")
+	}
 
-	stdout, stderr, err := r.run(ctx, opts.WorkingDir, args, opts.ProjectName)
+	stdout, stderr, err := r.run(ctx, process.Command{Dir: opts.WorkingDir, Args: args, Env: composeEnv(opts.ProjectName)})
 	if err != nil {
 		return nil, composeError("docker compose config", err, stderr)
 	}
@@ -66,6 +75,38 @@ func (r *Runner) MergeFragments(ctx context.Context, opts MergeOptions) ([]byte,
 	return stdout, nil
 }
 
+// Version returns the docker compose version (e.g. 2.29.1), without a leading v.
+func (r *Runner) Version(ctx context.Context) (string, error) {
+	stdout, stderr, err := r.run(ctx, process.Command{Args: []string{"version", "--short"}})
+	if err != nil {
+		return "", composeError("docker compose version", err, stderr)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(stdout)), "v"), nil
+}
+
+// Engine describes the Docker engine compose talks to.
+type Engine struct {
+	Version string
+	OS      string
+	Arch    string
+}
+
+// EngineInfo queries the Docker engine (server) version and platform.
+func (r *Runner) EngineInfo(ctx context.Context) (*Engine, error) {
+	stdout, stderr, err := r.exec.Run(ctx, process.Command{
+		Name: "docker",
+		Args: []string{"version", "--format", "{{.Server.Version}} {{.Server.Os}} {{.Server.Arch}}"},
+	})
+	if err != nil {
+		return nil, composeError("docker version", err, stderr)
+	}
+	fields := strings.Fields(string(stdout))
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected docker version output %q", strings.TrimSpace(string(stdout)))
+	}
+	return &Engine{Version: fields[0], OS: fields[1], Arch: fields[2]}, nil
+}
+
 // Run executes docker compose commands (up/down/logs/etc) in the runtime directory.
 func (r *Runner) Run(ctx context.Context, opts CommandOptions) error {
 	if opts.WorkingDir == "" {
@@ -75,7 +116,7 @@ func (r *Runner) Run(ctx context.Context, opts CommandOptions) error {
 		return errors.New("docker compose arguments are required")
 	}
 
-	_, stderr, err := r.run(ctx, opts.WorkingDir, opts.Args, "")
+	_, stderr, err := r.run(ctx, process.Command{Dir: opts.WorkingDir, Args: opts.Args, Stdout: opts.Stdout, Stderr: opts.Stderr})
 	if err != nil {
 		return composeError("docker compose", err, stderr)
 	}
@@ -91,20 +132,19 @@ func (r *Runner) RunWithOutput(ctx context.Context, opts CommandOptions) ([]byte
 		return nil, errors.New("docker compose arguments are required")
 	}
 
-	stdout, stderr, err := r.run(ctx, opts.WorkingDir, opts.Args, "")
+	stdout, stderr, err := r.run(ctx, process.Command{Dir: opts.WorkingDir, Args: opts.Args})
 	if err != nil {
 		return nil, composeError("docker compose", err, stderr)
 	}
 	return stdout, nil
 }
 
-func (r *Runner) run(ctx context.Context, dir string, args []string, project string) ([]byte, []byte, error) {
-	stdout, stderr, err := r.exec.Run(ctx, process.Command{
-		Name: r.primary[0],
-		Args: append(append([]string{}, r.primary[1:]...), args...),
-		Dir:  dir,
-		Env:  composeEnv(project),
-	})
+// run executes cmd, whose Args are the compose arguments, with docker compose and falls
+// back to the standalone docker-compose binary.
+func (r *Runner) run(ctx context.Context, cmd process.Command) ([]byte, []byte, error) {
+	args := cmd.Args
+	cmd.Name, cmd.Args = r.primary[0], append(append([]string{}, r.primary[1:]...), args...)
+	stdout, stderr, err := r.exec.Run(ctx, cmd)
 	if err == nil {
 		return stdout, stderr, nil
 	}
@@ -112,12 +152,8 @@ func (r *Runner) run(ctx context.Context, dir string, args []string, project str
 		return stdout, stderr, err
 	}
 
-	return r.exec.Run(ctx, process.Command{
-		Name: r.fallback[0],
-		Args: append(append([]string{}, r.fallback[1:]...), args...),
-		Dir:  dir,
-		Env:  composeEnv(project),
-	})
+	cmd.Name, cmd.Args = r.fallback[0], append(append([]string{}, r.fallback[1:]...), args...)
+	return r.exec.Run(ctx, cmd)
 }
 
 func composeEnv(project string) []string {
@@ -0,0 +1,335 @@
+// Package hooks implements chart lifecycle hooks: compose services and scripts declared
+// under templates/hooks/ that run around install, upgrade and uninstall.
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Phase names a point in the release lifecycle at which hooks run.
+type Phase string
+
+const (
+	PreInstall    Phase = "pre-install"
+	PostInstall   Phase = "post-install"
+	PreUpgrade    Phase = "pre-upgrade"
+	PostUpgrade   Phase = "post-upgrade"
+	PreUninstall  Phase = "pre-uninstall"
+	PostUninstall Phase = "post-uninstall"
+)
+
+var phases = map[Phase]bool{
+	PreInstall: true, PostInstall: true,
+	PreUpgrade: true, PostUpgrade: true,
+	PreUninstall: true, PostUninstall: true,
+}
+
+// FailurePolicy decides what happens to the lifecycle operation when a hook fails.
+type FailurePolicy string
+
+const (
+	Abort    FailurePolicy = "abort"    // stop the operation (default)
+	Continue FailurePolicy = "continue" // record the failure and carry on
+)
+
+// Kind tells how a hook is executed.
+type Kind string
+
+const (
+	KindService Kind = "service" // `docker compose run --rm <service>`
+	KindScript  Kind = "script"  // executable in the release's hooks/ directory
+)
+
+const (
+	// Extension is the service-level compose extension that turns a service into a hook.
+	Extension = "x-composepack-hook"
+	// ScriptDirective starts the comment line declaring a script hook.
+	ScriptDirective = "# composepack-hook:"
+	// Profile keeps hook services out of `docker compose up`; `run` still starts them.
+	Profile = "composepack-hooks"
+	// Dir holds rendered hook scripts inside a release directory.
+	Dir = "hooks"
+	// DefaultTimeout bounds a hook that declares no timeout.
+	DefaultTimeout = 5 * time.Minute
+)
+
+// Spec is a hook declaration, written as the x-composepack-hook mapping of a service or
+// after the `# composepack-hook:` directive of a script.
+type Spec struct {
+	Phases    []Phase       `json:"phases"`
+	Weight    int           `json:"weight,omitempty"`    // lower weights run first
+	Timeout   string        `json:"timeout,omitempty"`   // Go duration; DefaultTimeout when empty
+	OnFailure FailurePolicy `json:"onFailure,omitempty"` // abort (default) or continue
+}
+
+// Hook is a declared hook as recorded in release metadata.
+type Hook struct {
+	Name string `json:"name"` // service name, or script path below hooks/
+	Kind Kind   `json:"kind"`
+	Spec
+}
+
+// Set is the outcome of parsing a chart's rendered hook templates.
+type Set struct {
+	Hooks []Hook
+	// Fragments are the compose files declaring hook services, ready to merge with the
+	// chart's fragments: the annotation is stripped and services are moved to Profile.
+	Fragments map[string][]byte
+	Scripts   map[string][]byte // script hooks by path below hooks/
+}
+
+// Parse classifies rendered templates/hooks output: YAML files declare hook services,
+// anything else is a script hook. Every service and script must declare a Spec.
+func Parse(rendered map[string][]byte) (*Set, error) {
+	set := &Set{Fragments: map[string][]byte{}, Scripts: map[string][]byte{}}
+
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := map[string]string{}
+	for _, name := range names {
+		data := rendered[name]
+		if ext := path.Ext(name); ext == ".yaml" || ext == ".yml" {
+			fragment, declared, err := parseFragment(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			for _, hook := range declared {
+				if other, ok := services[hook.Name]; ok {
+					return nil, fmt.Errorf("%s: hook service %s is already declared in %s", name, hook.Name, other)
+				}
+				services[hook.Name] = name
+			}
+			set.Hooks = append(set.Hooks, declared...)
+			set.Fragments[name] = fragment
+			continue
+		}
+
+		spec, err := parseDirective(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		set.Hooks = append(set.Hooks, Hook{Name: name, Kind: KindScript, Spec: *spec})
+		set.Scripts[name] = data
+	}
+	return set, nil
+}
+
+// Add merges the hooks of a dependency into s. Its scripts and hook fragments move below
+// prefix; its hook services keep their names, which must not clash with those of s.
+func (s *Set) Add(prefix string, dep *Set) error {
+	services := map[string]bool{}
+	for _, hook := range s.Hooks {
+		if hook.Kind == KindService {
+			services[hook.Name] = true
+		}
+	}
+	for _, hook := range dep.Hooks {
+		switch {
+		case hook.Kind == KindScript:
+			hook.Name = path.Join(prefix, hook.Name)
+		case services[hook.Name]:
+			return fmt.Errorf("hook service %s is already declared", hook.Name)
+		}
+		s.Hooks = append(s.Hooks, hook)
+	}
+	for name, data := range dep.Fragments {
+		s.Fragments[path.Join(prefix, name)] = data
+	}
+	for name, data := range dep.Scripts {
+		s.Scripts[path.Join(prefix, name)] = data
+	}
+	return nil
+}
+
+func parseFragment(data []byte) ([]byte, []Hook, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parse compose fragment: %w", err)
+	}
+	services, _ := doc["services"].(map[string]any)
+	if len(services) == 0 {
+		return nil, nil, errors.New("declares no services")
+	}
+
+	var hooks []Hook
+	for name, raw := range services {
+		svc, ok := raw.(map[string]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("service %s must be a mapping", name)
+		}
+		annotation, ok := svc[Extension]
+		if !ok {
+			return nil, nil, fmt.Errorf("service %s has no %s annotation", name, Extension)
+		}
+		encoded, err := yaml.Marshal(annotation)
+		if err != nil {
+			return nil, nil, err
+		}
+		spec, err := decodeSpec(encoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		delete(svc, Extension)
+		if _, ok := svc["profiles"]; !ok {
+			svc["profiles"] = []any{Profile}
+		}
+		hooks = append(hooks, Hook{Name: name, Kind: KindService, Spec: *spec})
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode compose fragment: %w", err)
+	}
+	return out, hooks, nil
+}
+
+func parseDirective(data []byte) (*Spec, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, ScriptDirective); ok {
+			return decodeSpec([]byte(rest))
+		}
+	}
+	return nil, fmt.Errorf("script hooks need a %q line, e.g. %s {phases: [pre-upgrade], weight: 0}", ScriptDirective, ScriptDirective)
+}
+
+func decodeSpec(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid hook declaration: %w", err)
+	}
+	if len(spec.Phases) == 0 {
+		return nil, errors.New("hook declares no phases")
+	}
+	for _, phase := range spec.Phases {
+		if !phases[phase] {
+			return nil, fmt.Errorf("unknown hook phase %q", phase)
+		}
+	}
+	switch spec.OnFailure {
+	case "", Abort, Continue:
+	default:
+		return nil, fmt.Errorf("onFailure must be %s or %s, got %q", Abort, Continue, spec.OnFailure)
+	}
+	if spec.Timeout != "" {
+		if d, err := time.ParseDuration(spec.Timeout); err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid hook timeout %q", spec.Timeout)
+		}
+	}
+	return &spec, nil
+}
+
+// timeout returns the hook's time limit.
+func (s Spec) timeout() time.Duration {
+	if d, err := time.ParseDuration(s.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultTimeout
+}
+
+// ForPhase returns the hooks declared for phase, ordered by weight and then name.
+func ForPhase(hooks []Hook, phase Phase) []Hook {
+	var out []Hook
+	for _, hook := range hooks {
+		for _, p := range hook.Phases {
+			if p == phase {
+				out = append(out, hook)
+				break
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Weight != out[j].Weight {
+			return out[i].Weight < out[j].Weight
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// Executor runs single hooks for a release.
+type Executor interface {
+	RunService(ctx context.Context, service string) error
+	RunScript(ctx context.Context, script string, phase Phase) error
+}
+
+// Result records one hook execution.
+type Result struct {
+	Hook      string    `json:"hook"`
+	Kind      Kind      `json:"kind"`
+	Phase     Phase     `json:"phase"`
+	Succeeded bool      `json:"succeeded"`
+	Skipped   bool      `json:"skipped,omitempty"` // recorded by Skip; the hook did not run
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  string    `json:"duration"`
+}
+
+// Skip records the hooks of a phase as skipped, in the order Run would execute them.
+func Skip(hooks []Hook, phase Phase) []Result {
+	var results []Result
+	for _, hook := range ForPhase(hooks, phase) {
+		results = append(results, Result{Hook: hook.Name, Kind: hook.Kind, Phase: phase, Skipped: true, StartedAt: time.Now().UTC(), Duration: "0s"})
+	}
+	return results
+}
+
+// Run executes the hooks of a phase in order, each bounded by its timeout. A failing hook
+// stops the phase with an error unless it is declared with onFailure: continue. The results
+// of every hook that ran are returned in both cases.
+func Run(ctx context.Context, exec Executor, hooks []Hook, phase Phase) ([]Result, error) {
+	var results []Result
+	for _, hook := range ForPhase(hooks, phase) {
+		started := time.Now()
+		hookCtx, cancel := context.WithTimeout(ctx, hook.timeout())
+		var err error
+		switch hook.Kind {
+		case KindService:
+			err = exec.RunService(hookCtx, hook.Name)
+		case KindScript:
+			err = exec.RunScript(hookCtx, hook.Name, phase)
+		default:
+			err = fmt.Errorf("unknown hook kind %q", hook.Kind)
+		}
+		if err != nil && errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", hook.timeout(), err)
+		}
+		cancel()
+
+		result := Result{
+			Hook:      hook.Name,
+			Kind:      hook.Kind,
+			Phase:     phase,
+			Succeeded: err == nil,
+			StartedAt: started.UTC(),
+			Duration:  time.Since(started).Round(time.Millisecond).String(),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+
+		if err != nil && hook.OnFailure != Continue {
+			return results, fmt.Errorf("%s hook %s failed: %w", phase, hook.Name, err)
+		}
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,96 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"composepack/internal/infra/process"
+)
+
+// CLI implements Docker by running the docker command line.
+type CLI struct {
+	exec *process.Runner
+}
+
+// NewCLI constructs a docker CLI client using the provided process runner.
+func NewCLI(execRunner *process.Runner) *CLI {
+	if execRunner == nil {
+		execRunner = process.NewRunner()
+	}
+	return &CLI{exec: execRunner}
+}
+
+// ImageExists implements Docker.
+func (c *CLI) ImageExists(ctx context.Context, ref string) (bool, error) {
+	_, _, err := c.exec.Run(ctx, process.Command{Name: "docker", Args: []string{"image", "inspect", "--format", "{{.Id}}", ref}})
+	if process.IsNotFound(err) {
+		return false, fmt.Errorf("docker not found in PATH: %w", err)
+	}
+	// inspect fails for missing images; any other problem surfaces when pulling.
+	return err == nil, nil
+}
+
+// RepoDigest implements Docker.
+func (c *CLI) RepoDigest(ctx context.Context, ref string) (string, error) {
+	stdout, _, err := c.exec.Run(ctx, process.Command{Name: "docker", Args: []string{"image", "inspect", "--format", "{{json .RepoDigests}}", ref}})
+	if process.IsNotFound(err) {
+		return "", fmt.Errorf("docker not found in PATH: %w", err)
+	}
+	if err != nil {
+		return "", nil
+	}
+	var repoDigests []string
+	if err := json.Unmarshal(bytes.TrimSpace(stdout), &repoDigests); err != nil {
+		return "", fmt.Errorf("parse docker image inspect output for %s: %w", ref, err)
+	}
+	return matchRepoDigest(ref, repoDigests), nil
+}
+
+// matchRepoDigest picks the entry of an image's RepoDigests (name@sha256:...) belonging to
+// the repository of ref. Docker shortens Docker Hub names, so those are compared normalized.
+func matchRepoDigest(ref string, repoDigests []string) string {
+	name, _, _ := SplitRef(ref)
+	for _, entry := range repoDigests {
+		repo, digest, ok := strings.Cut(entry, "@")
+		if ok && normalizeName(repo) == normalizeName(name) {
+			return digest
+		}
+	}
+	return ""
+}
+
+func normalizeName(name string) string {
+	name = strings.TrimPrefix(name, "docker.io/")
+	name = strings.TrimPrefix(name, "index.docker.io/")
+	return strings.TrimPrefix(name, "library/")
+}
+
+// Pull implements Docker.
+func (c *CLI) Pull(ctx context.Context, ref string) error {
+	return c.run(ctx, nil, "pull", "--quiet", ref)
+}
+
+// Save implements Docker.
+func (c *CLI) Save(ctx context.Context, output string, refs []string) error {
+	return c.run(ctx, nil, append([]string{"save", "--output", output}, refs...)...)
+}
+
+// Load implements Docker.
+func (c *CLI) Load(ctx context.Context, r io.Reader) error {
+	return c.run(ctx, r, "load", "--quiet")
+}
+
+func (c *CLI) run(ctx context.Context, stdin io.Reader, args ...string) error {
+	_, stderr, err := c.exec.Run(ctx, process.Command{Name: "docker", Args: args, Stdin: stdin})
+	if err == nil {
+		return nil
+	}
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return fmt.Errorf("docker %s failed: %w: %s", args[0], err, msg)
+	}
+	return fmt.Errorf("docker %s failed: %w", args[0], err)
+}
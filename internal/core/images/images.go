@@ -0,0 +1,249 @@
+// Package images bundles the container images a chart needs into its archive, so charts
+// can be installed on hosts without registry access, and loads them back into Docker.
+package images
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"composepack/internal/core/chart"
+)
+
+// Paths of a bundle inside a chart archive. The manifest sorts before the image tarball,
+// so both can be read in a single pass over a reproducible archive.
+var (
+	ManifestPath = path.Join(chart.ImagesDir, "manifest.yaml")
+	TarballPath  = path.Join(chart.ImagesDir, "saved-images.tar")
+)
+
+// Image is one image referenced by the rendered compose file.
+type Image struct {
+	Ref      string   `json:"ref"`
+	Services []string `json:"services"`
+}
+
+// Manifest records the images saved into a chart archive.
+type Manifest struct {
+	Images []Image `json:"images"`
+	Digest string  `json:"digest"` // sha256:<hex> of the docker save tarball
+}
+
+// Refs lists the image references of the manifest.
+func (m *Manifest) Refs() []string {
+	refs := make([]string, 0, len(m.Images))
+	for _, image := range m.Images {
+		refs = append(refs, image.Ref)
+	}
+	return refs
+}
+
+// Docker is the subset of the docker CLI used for bundles. CLI implements it;
+// imagestest.Docker is an in-memory fake for tests.
+type Docker interface {
+	// ImageExists reports whether ref is present in the local image store.
+	ImageExists(ctx context.Context, ref string) (bool, error)
+	// RepoDigest returns the registry digest (sha256:<hex>) of a local image, or "" when
+	// the image is missing or was never pushed to or pulled from a registry.
+	RepoDigest(ctx context.Context, ref string) (string, error)
+	Pull(ctx context.Context, ref string) error
+	// Save writes refs as a `docker save` tarball to output.
+	Save(ctx context.Context, output string, refs []string) error
+	// Load imports a `docker save` tarball read from r.
+	Load(ctx context.Context, r io.Reader) error
+}
+
+// List collects the images of a merged compose file, sorted by reference. Services that
+// only have a build section are skipped, since there is nothing to pull.
+func List(compose []byte) ([]Image, error) {
+	var doc struct {
+		Services map[string]struct {
+			Image string `json:"image"`
+		} `json:"services"`
+	}
+	if err := yaml.Unmarshal(compose, &doc); err != nil {
+		return nil, fmt.Errorf("parse compose file: %w", err)
+	}
+
+	byRef := map[string]*Image{}
+	for name, svc := range doc.Services {
+		if svc.Image == "" {
+			continue
+		}
+		image := byRef[svc.Image]
+		if image == nil {
+			image = &Image{Ref: svc.Image}
+			byRef[svc.Image] = image
+		}
+		image.Services = append(image.Services, name)
+	}
+
+	out := make([]Image, 0, len(byRef))
+	for _, image := range byRef {
+		sort.Strings(image.Services)
+		out = append(out, *image)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Ref < out[j].Ref })
+	return out, nil
+}
+
+// Save pulls images missing from the local store and writes the tarball and manifest
+// into dir, laid out as in a chart archive (dir/images/...). It returns the manifest.
+func Save(ctx context.Context, docker Docker, dir string, images []Image) (*Manifest, error) {
+	if len(images) == 0 {
+		return nil, errors.New("the rendered chart references no images to bundle")
+	}
+	manifest := &Manifest{Images: images}
+	for _, ref := range manifest.Refs() {
+		exists, err := docker.ImageExists(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			continue
+		}
+		if err := docker.Pull(ctx, ref); err != nil {
+			return nil, fmt.Errorf("pull %s: %w", ref, err)
+		}
+	}
+
+	tarball := filepath.Join(dir, filepath.FromSlash(TarballPath))
+	if err := os.MkdirAll(filepath.Dir(tarball), 0o755); err != nil {
+		return nil, fmt.Errorf("create bundle directory: %w", err)
+	}
+	if err := docker.Save(ctx, tarball, manifest.Refs()); err != nil {
+		return nil, fmt.Errorf("save images: %w", err)
+	}
+	digest, err := chart.FileDigest(tarball)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Digest = digest
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("encode image manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(ManifestPath)), data, 0o644); err != nil {
+		return nil, fmt.Errorf("write image manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// ReadManifest returns the image manifest of a chart archive, or nil when the archive
+// bundles no images.
+func ReadManifest(archive string) (*Manifest, error) {
+	var manifest *Manifest
+	err := walkBundle(archive, func(name string, r io.Reader) (bool, error) {
+		if name != ManifestPath {
+			return true, nil
+		}
+		var err error
+		manifest, err = decodeManifest(r)
+		return false, err
+	})
+	return manifest, err
+}
+
+// Load streams the bundled tarball of a chart archive into docker load, checking it
+// against the manifest digest. It returns the manifest, or nil when nothing is bundled.
+func Load(ctx context.Context, docker Docker, archive string) (*Manifest, error) {
+	var (
+		manifest *Manifest
+		loaded   bool
+	)
+	err := walkBundle(archive, func(name string, r io.Reader) (bool, error) {
+		switch name {
+		case ManifestPath:
+			var err error
+			manifest, err = decodeManifest(r)
+			return err == nil, err
+		case TarballPath:
+			if manifest == nil {
+				return false, fmt.Errorf("%s: %s precedes %s", archive, TarballPath, ManifestPath)
+			}
+			hash := sha256.New()
+			if err := docker.Load(ctx, io.TeeReader(r, hash)); err != nil {
+				return false, fmt.Errorf("load images: %w", err)
+			}
+			// docker load may stop before EOF; hash whatever is left.
+			if _, err := io.Copy(hash, r); err != nil {
+				return false, err
+			}
+			if got := "sha256:" + hex.EncodeToString(hash.Sum(nil)); got != manifest.Digest {
+				return false, fmt.Errorf("bundled images of %s do not match the manifest (expected %s, got %s)", archive, manifest.Digest, got)
+			}
+			loaded = true
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil && !loaded {
+		return nil, fmt.Errorf("%s lists bundled images but contains no %s", archive, TarballPath)
+	}
+	return manifest, nil
+}
+
+// walkBundle visits the regular files below images/ in a chart archive until visit
+// returns false.
+func walkBundle(archive string, visit func(name string, r io.Reader) (bool, error)) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("read archive %s: %w", archive, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read archive %s: %w", archive, err)
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || !strings.HasPrefix(name, chart.ImagesDir+"/") {
+			continue
+		}
+		more, err := visit(name, tr)
+		if err != nil || !more {
+			return err
+		}
+	}
+}
+
+func decodeManifest(r io.Reader) (*Manifest, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read image manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse image manifest: %w", err)
+	}
+	if chart.ValidateDigest(manifest.Digest) != nil {
+		return nil, fmt.Errorf("image manifest has an invalid digest %q", manifest.Digest)
+	}
+	return &manifest, nil
+}
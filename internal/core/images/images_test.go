@@ -0,0 +1,150 @@
+package images_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/core/images"
+	"composepack/internal/core/images/imagestest"
+	"composepack/internal/packager"
+	"composepack/internal/util/fileloader"
+)
+
+func TestList(t *testing.T) {
+	compose := []byte(`
+name: demo
+services:
+  web:
+    image: nginx:1.27
+  worker:
+    image: example/app:2.0
+  api:
+    image: example/app:2.0
+  builder:
+    build:
+      context: .
+`)
+	got, err := images.List(compose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []images.Image{
+		{Ref: "example/app:2.0", Services: []string{"api", "worker"}},
+		{Ref: "nginx:1.27", Services: []string{"web"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("List() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	docker := imagestest.NewDocker()
+	docker.AddLocal("nginx:1.27", []byte("nginx layers"))
+	docker.AddRemote("redis:7", []byte("redis layers"), "sha256:1111")
+	list := []images.Image{{Ref: "nginx:1.27", Services: []string{"web"}}, {Ref: "redis:7", Services: []string{"cache"}}}
+
+	bundleDir := t.TempDir()
+	manifest, err := images.Save(ctx, docker, bundleDir, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pulled := docker.Pulled(); !reflect.DeepEqual(pulled, []string{"redis:7"}) {
+		t.Fatalf("pulled %v, want only the missing image", pulled)
+	}
+	if chart.ValidateDigest(manifest.Digest) != nil {
+		t.Fatalf("manifest digest %q is not a sha256 digest", manifest.Digest)
+	}
+
+	archive := packageWithBundle(t, bundleDir)
+	read, err := images.ReadManifest(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, manifest) {
+		t.Fatalf("ReadManifest() = %+v, want %+v", read, manifest)
+	}
+
+	target := imagestest.NewDocker()
+	loaded, err := images.Load(ctx, target, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, manifest) {
+		t.Fatalf("Load() = %+v, want %+v", loaded, manifest)
+	}
+	for ref, want := range map[string]string{"nginx:1.27": "nginx layers", "redis:7": "redis layers"} {
+		if got, ok := target.Local(ref); !ok || string(got) != want {
+			t.Errorf("image %s after load = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestLoadDigestMismatch(t *testing.T) {
+	ctx := context.Background()
+	docker := imagestest.NewDocker()
+	docker.AddLocal("nginx:1.27", []byte("nginx layers"))
+
+	bundleDir := t.TempDir()
+	if _, err := images.Save(ctx, docker, bundleDir, []images.Image{{Ref: "nginx:1.27", Services: []string{"web"}}}); err != nil {
+		t.Fatal(err)
+	}
+	// Swap the tarball for other images after the manifest was written.
+	docker.AddLocal("nginx:1.27", []byte("tampered layers"))
+	if err := docker.Save(ctx, filepath.Join(bundleDir, filepath.FromSlash(images.TarballPath)), []string{"nginx:1.27"}); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := packageWithBundle(t, bundleDir)
+	_, err := images.Load(ctx, imagestest.NewDocker(), archive)
+	if err == nil || !strings.Contains(err.Error(), "do not match the manifest") {
+		t.Fatalf("Load() error = %v, want a digest mismatch", err)
+	}
+}
+
+func TestReadManifestWithoutBundle(t *testing.T) {
+	archive := packageWithBundle(t, "")
+	manifest, err := images.ReadManifest(archive)
+	if err != nil || manifest != nil {
+		t.Fatalf("ReadManifest() = %v, %v; want nil, nil", manifest, err)
+	}
+}
+
+// packageWithBundle packages a minimal chart, adding the bundle saved in bundleDir (if any)
+// the way `composepack package --bundle-images` does.
+func packageWithBundle(t *testing.T, bundleDir string) string {
+	t.Helper()
+	chartDir := t.TempDir()
+	writeFile(t, filepath.Join(chartDir, chart.MetadataFile), "apiVersion: v2\nname: demo\nversion: 0.1.0\n")
+	writeFile(t, filepath.Join(chartDir, chart.ValuesFile), "")
+	writeFile(t, filepath.Join(chartDir, filepath.FromSlash(chart.TemplatesCompose), "web.yaml"), "services:\n  web:\n    image: nginx:1.27\n")
+
+	opts := packager.Options{ChartPath: chartDir, Destination: t.TempDir()}
+	if bundleDir != "" {
+		opts.ExtraFiles = map[string]string{}
+		for _, name := range []string{images.ManifestPath, images.TarballPath} {
+			opts.ExtraFiles[name] = filepath.Join(bundleDir, filepath.FromSlash(name))
+		}
+	}
+	loader := chart.NewFileSystemChartLoader(fileloader.NewFileSystemLoader())
+	result, err := packager.PackageChart(context.Background(), loader, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Path
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,146 @@
+// Package imagestest provides an in-memory stand-in for the docker CLI used by the images
+// package, so bundles can be saved and loaded without Docker.
+package imagestest
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Docker implements images.Docker in memory. An image is an opaque blob keyed by its
+// reference; Save writes the requested images as a tar with one entry per reference and
+// Load imports such a tar back into the local store.
+type Docker struct {
+	mu       sync.Mutex
+	local    map[string][]byte // local image store
+	registry map[string][]byte // images Pull can fetch
+	digests  map[string]string // repo digests of local images
+	pulled   []string
+}
+
+// NewDocker returns a Docker with an empty local store and registry.
+func NewDocker() *Docker {
+	return &Docker{local: map[string][]byte{}, registry: map[string][]byte{}, digests: map[string]string{}}
+}
+
+// AddLocal puts an image into the local store.
+func (d *Docker) AddLocal(ref string, content []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.local[ref] = content
+}
+
+// AddRemote makes an image available to Pull, with the repo digest it gets once pulled.
+func (d *Docker) AddRemote(ref string, content []byte, digest string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.registry[ref] = content
+	d.digests[ref] = digest
+}
+
+// Local returns the content of a local image.
+func (d *Docker) Local(ref string) ([]byte, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	content, ok := d.local[ref]
+	return content, ok
+}
+
+// Pulled lists the references pulled so far, in order.
+func (d *Docker) Pulled() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string{}, d.pulled...)
+}
+
+// ImageExists implements images.Docker.
+func (d *Docker) ImageExists(_ context.Context, ref string) (bool, error) {
+	_, ok := d.Local(ref)
+	return ok, nil
+}
+
+// RepoDigest implements images.Docker.
+func (d *Docker) RepoDigest(_ context.Context, ref string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.local[ref]; !ok {
+		return "", nil
+	}
+	return d.digests[ref], nil
+}
+
+// Pull implements images.Docker.
+func (d *Docker) Pull(_ context.Context, ref string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	content, ok := d.registry[ref]
+	if !ok {
+		return fmt.Errorf("pull access denied for %s", ref)
+	}
+	d.local[ref] = content
+	d.pulled = append(d.pulled, ref)
+	return nil
+}
+
+// Save implements images.Docker. The tar is deterministic for a given set of images.
+func (d *Docker) Save(_ context.Context, output string, refs []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sorted := append([]string{}, refs...)
+	sort.Strings(sorted)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, ref := range sorted {
+		content, ok := d.local[ref]
+		if !ok {
+			return fmt.Errorf("reference does not exist: %s", ref)
+		}
+		header := &tar.Header{Name: ref, Mode: 0o644, Size: int64(len(content)), ModTime: time.Unix(0, 0)}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(output, buf.Bytes(), 0o644)
+}
+
+// Load implements images.Docker.
+func (d *Docker) Load(_ context.Context, r io.Reader) error {
+	loaded := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid image tarball: %w", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		loaded[header.Name] = content
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ref, content := range loaded {
+		d.local[ref] = content
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package images
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SplitRef splits an image reference into its name, tag and digest. Either of the latter
+// may be empty; a reference without both implicitly uses the latest tag.
+func SplitRef(ref string) (name, tag, digest string) {
+	name, digest, _ = strings.Cut(ref, "@")
+	slash := strings.LastIndex(name, "/")
+	if colon := strings.LastIndex(name, ":"); colon > slash {
+		name, tag = name[:colon], name[colon+1:]
+	}
+	return name, tag, digest
+}
+
+// Pinned reports whether ref carries a digest.
+func Pinned(ref string) bool {
+	_, _, digest := SplitRef(ref)
+	return digest != ""
+}
+
+// WithDigest returns ref pinned to digest, keeping its tag for readability.
+func WithDigest(ref, digest string) string {
+	name, tag, _ := SplitRef(ref)
+	if tag != "" {
+		name += ":" + tag
+	}
+	return name + "@" + digest
+}
+
+var imageLine = regexp.MustCompile(`(?m)^(\s+image:\s*)(["']?)([^"'\s#]+)(["']?)[ \t]*$`)
+
+// Pin rewrites the image references of a merged compose file to the digests in digests
+// (keyed by the reference as written). Lines are edited in place so the rest of the file
+// keeps its layout.
+func Pin(compose []byte, digests map[string]string) []byte {
+	return imageLine.ReplaceAllFunc(compose, func(line []byte) []byte {
+		m := imageLine.FindSubmatch(line)
+		ref := string(m[3])
+		digest, ok := digests[ref]
+		if !ok || Pinned(ref) {
+			return line
+		}
+		return []byte(string(m[1]) + string(m[2]) + WithDigest(ref, digest) + string(m[4]))
+	})
+}
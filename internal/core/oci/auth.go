@@ -0,0 +1,143 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// dockerHubAuthKey is the key Docker uses for Docker Hub credentials in config.json.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// Credentials are the username/secret pair presented to a registry.
+type Credentials struct {
+	Username string
+	Secret   string // password or identity token
+}
+
+// CredentialStore looks up registry credentials. A nil result means anonymous access.
+type CredentialStore interface {
+	Get(ctx context.Context, registry string) (*Credentials, error)
+}
+
+// DockerConfig reads credentials the way the docker CLI does: credHelpers, then inline
+// auths from config.json, then the default credsStore.
+type DockerConfig struct {
+	Path string // config.json location; defaults to $DOCKER_CONFIG/config.json or ~/.docker/config.json
+}
+
+type dockerConfigFile struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		Username      string `json:"username"`
+		Password      string `json:"password"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// Get implements CredentialStore.
+func (d DockerConfig) Get(ctx context.Context, registry string) (*Credentials, error) {
+	cfg, err := d.load()
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+
+	keys := []string{registry, "https://" + registry, "http://" + registry}
+	if registry == "docker.io" || registry == "registry-1.docker.io" || registry == "index.docker.io" {
+		keys = append(keys, dockerHubAuthKey)
+	}
+
+	for _, key := range keys {
+		if helper, ok := cfg.CredHelpers[key]; ok {
+			return credentialHelper(ctx, helper, key)
+		}
+	}
+	for _, key := range keys {
+		entry, ok := cfg.Auths[key]
+		if !ok {
+			continue
+		}
+		switch {
+		case entry.IdentityToken != "":
+			return &Credentials{Username: entry.Username, Secret: entry.IdentityToken}, nil
+		case entry.Auth != "":
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("decode docker auth for %s: %w", key, err)
+			}
+			user, pass, _ := strings.Cut(string(decoded), ":")
+			return &Credentials{Username: user, Secret: pass}, nil
+		case entry.Username != "":
+			return &Credentials{Username: entry.Username, Secret: entry.Password}, nil
+		}
+	}
+	if cfg.CredsStore != "" {
+		return credentialHelper(ctx, cfg.CredsStore, registry)
+	}
+	return nil, nil
+}
+
+func (d DockerConfig) load() (*dockerConfigFile, error) {
+	path := d.Path
+	if path == "" {
+		dir := os.Getenv("DOCKER_CONFIG")
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, nil
+			}
+			dir = filepath.Join(home, ".docker")
+		}
+		path = filepath.Join(dir, "config.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read docker config: %w", err)
+	}
+	var cfg dockerConfigFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse docker config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// credentialHelper runs `docker-credential-<helper> get` for a server URL.
+func credentialHelper(ctx context.Context, helper, server string) (*Credentials, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stdout.String()+stderr.String(), "credentials not found") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("docker-credential-%s get %s: %w", helper, server, err)
+	}
+
+	var out struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("parse docker-credential-%s output: %w", helper, err)
+	}
+	if out.Username == "" && out.Secret == "" {
+		return nil, nil
+	}
+	return &Credentials{Username: out.Username, Secret: out.Secret}, nil
+}
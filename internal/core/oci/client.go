@@ -0,0 +1,567 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/infra/download"
+)
+
+// Media types of chart artifacts.
+const (
+	ManifestMediaType   = "application/vnd.oci.image.manifest.v1+json"
+	ArtifactType        = "application/vnd.composepack.chart.v1"
+	ConfigMediaType     = "application/vnd.composepack.chart.config.v1+yaml" // raw Chart.yaml
+	ContentMediaType    = "application/vnd.composepack.chart.content.v1.tar+gzip"
+	ProvenanceMediaType = "application/vnd.composepack.chart.provenance.v1+yaml" // detached signature
+
+	annotationTitle   = "org.opencontainers.image.title"
+	annotationVersion = "org.opencontainers.image.version"
+)
+
+// Descriptor points at a blob in a registry.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an OCI image manifest carrying a chart.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// PushOptions describe a packaged chart to upload.
+type PushOptions struct {
+	Name       string // chart name, appended to the target repository
+	Version    string // chart version, used as tag
+	ChartYAML  []byte // raw Chart.yaml, stored as the config blob
+	Archive    []byte // .cpack.tgz contents, stored as the first layer
+	Provenance []byte // optional .prov signature, stored as a second layer
+}
+
+// Artifact is a chart pulled from a registry.
+type Artifact struct {
+	Archive    []byte
+	Provenance []byte // nil when the chart was pushed unsigned
+}
+
+// PushResult reports where a chart was stored.
+type PushResult struct {
+	Ref    Reference
+	Digest string // manifest digest
+}
+
+// ErrOffline is returned for any registry request attempted while Client.Offline is set.
+var ErrOffline = errors.New("offline mode: network access disabled")
+
+// defaultHTTPClient bounds requests of clients without download settings.
+var defaultHTTPClient = &http.Client{Timeout: download.DefaultTimeout}
+
+// Client talks to OCI distribution registries.
+type Client struct {
+	// Downloads supplies per-host HTTP clients built from the download settings (timeout,
+	// CA bundle, client certificates). HTTPClient is used when it is nil.
+	Downloads   *download.Client
+	HTTPClient  *http.Client
+	PlainHTTP   bool // use http:// for every registry (loopback registries always use it)
+	Offline     bool // fail every request with ErrOffline
+	Credentials CredentialStore
+
+	mu     sync.Mutex
+	tokens map[string]string // registry|scope -> Authorization header
+}
+
+// NewClient builds a registry client using docker credential configuration and the HTTP
+// settings of downloads.
+func NewClient(downloads *download.Client) *Client {
+	return &Client{Downloads: downloads, Credentials: DockerConfig{}}
+}
+
+// Push uploads a packaged chart to `<target>/<name>:<version>`.
+func (c *Client) Push(ctx context.Context, target string, opts PushOptions) (*PushResult, error) {
+	base, err := ParseReference(target)
+	if err != nil {
+		return nil, err
+	}
+	if base.Tag != "" {
+		return nil, fmt.Errorf("push target %s must not include a tag (the chart version is used)", target)
+	}
+	// Registries disallow '+' in tags, so build metadata is pushed as '_'.
+	ref := Reference{Registry: base.Registry, Repository: base.Repository + "/" + opts.Name, Tag: strings.ReplaceAll(opts.Version, "+", "_")}
+	scope := "repository:" + ref.Repository + ":pull,push"
+
+	config := descriptorFor(ConfigMediaType, opts.ChartYAML)
+	layer := descriptorFor(ContentMediaType, opts.Archive)
+	layer.Annotations = map[string]string{annotationTitle: fmt.Sprintf("%s-%s.cpack.tgz", opts.Name, opts.Version)}
+
+	if err := c.pushBlob(ctx, ref, scope, config, opts.ChartYAML); err != nil {
+		return nil, err
+	}
+	if err := c.pushBlob(ctx, ref, scope, layer, opts.Archive); err != nil {
+		return nil, err
+	}
+	layers := []Descriptor{layer}
+	if len(opts.Provenance) > 0 {
+		prov := descriptorFor(ProvenanceMediaType, opts.Provenance)
+		prov.Annotations = map[string]string{annotationTitle: layer.Annotations[annotationTitle] + chart.ProvenanceSuffix}
+		if err := c.pushBlob(ctx, ref, scope, prov, opts.Provenance); err != nil {
+			return nil, err
+		}
+		layers = append(layers, prov)
+	}
+
+	manifest, err := json.Marshal(Manifest{
+		SchemaVersion: 2,
+		MediaType:     ManifestMediaType,
+		ArtifactType:  ArtifactType,
+		Config:        config,
+		Layers:        layers,
+		Annotations:   map[string]string{annotationTitle: opts.Name, annotationVersion: opts.Version},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	resp, err := c.do(ctx, ref.Registry, scope, http.MethodPut, c.endpoint(ref, "manifests/"+ref.Tag), ManifestMediaType, manifest)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, statusError("push manifest", resp)
+	}
+	return &PushResult{Ref: ref, Digest: digestOf(manifest)}, nil
+}
+
+// FetchChart implements chart.OCIFetcher: it downloads the chart layer of
+// `oci://host/repo[:tag]` to a temporary archive. Without a tag, the highest tag satisfying
+// version (any version when empty) is used.
+func (c *Client) FetchChart(ctx context.Context, source, version string) (string, func(), error) {
+	ref, err := ParseReference(source)
+	if err != nil {
+		return "", nil, err
+	}
+	if ref.Tag == "" {
+		ref.Tag, err = c.resolveTag(ctx, ref, version)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	artifact, err := c.Pull(ctx, ref)
+	if err != nil {
+		return "", nil, err
+	}
+	tmp, err := os.CreateTemp("", "composepack-oci-*.cpack.tgz")
+	if err != nil {
+		return "", nil, fmt.Errorf("create temp file: %w", err)
+	}
+	path := tmp.Name()
+	cleanup := func() {
+		os.Remove(path)
+		os.Remove(path + chart.ProvenanceSuffix)
+	}
+	_, err = tmp.Write(artifact.Archive)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && artifact.Provenance != nil {
+		// The provenance file sits next to the archive, where chart verification looks for it.
+		err = os.WriteFile(path+chart.ProvenanceSuffix, artifact.Provenance, 0o600)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("save chart: %w", err)
+	}
+	return path, cleanup, nil
+}
+
+// Pull returns the chart archive stored at ref, with its provenance file when one was pushed.
+func (c *Client) Pull(ctx context.Context, ref Reference) (*Artifact, error) {
+	scope := "repository:" + ref.Repository + ":pull"
+	resp, err := c.do(ctx, ref.Registry, scope, http.MethodGet, c.endpoint(ref, "manifests/"+ref.Tag), "", nil, "Accept", ManifestMediaType)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("chart %s not found", ref)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("pull manifest", resp)
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest %s: %w", ref, err)
+	}
+
+	var content, provenance *Descriptor
+	for i := range manifest.Layers {
+		switch manifest.Layers[i].MediaType {
+		case ContentMediaType:
+			if content == nil {
+				content = &manifest.Layers[i]
+			}
+		case ProvenanceMediaType:
+			if provenance == nil {
+				provenance = &manifest.Layers[i]
+			}
+		}
+	}
+	if content == nil {
+		return nil, fmt.Errorf("%s is not a composepack chart (no %s layer)", ref, ContentMediaType)
+	}
+
+	artifact := &Artifact{}
+	if artifact.Archive, err = c.pullBlob(ctx, ref, scope, "chart layer", *content); err != nil {
+		return nil, err
+	}
+	if provenance != nil {
+		if artifact.Provenance, err = c.pullBlob(ctx, ref, scope, "provenance layer", *provenance); err != nil {
+			return nil, err
+		}
+	}
+	return artifact, nil
+}
+
+func (c *Client) pullBlob(ctx context.Context, ref Reference, scope, what string, desc Descriptor) ([]byte, error) {
+	blob, err := c.do(ctx, ref.Registry, scope, http.MethodGet, c.endpoint(ref, "blobs/"+desc.Digest), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Body.Close()
+	if blob.StatusCode != http.StatusOK {
+		return nil, statusError("pull "+what, blob)
+	}
+	if desc.Size > chart.MaxArchiveSize {
+		return nil, fmt.Errorf("%s of %s is %d bytes, more than the %d allowed", what, ref, desc.Size, chart.MaxArchiveSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(blob.Body, chart.MaxArchiveSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", what, err)
+	}
+	if len(data) > chart.MaxArchiveSize {
+		return nil, fmt.Errorf("%s of %s exceeds %d bytes", what, ref, chart.MaxArchiveSize)
+	}
+	if got := digestOf(data); got != desc.Digest {
+		return nil, fmt.Errorf("%s digest mismatch for %s (manifest %s, got %s)", what, ref, desc.Digest, got)
+	}
+	return data, nil
+}
+
+// Tags lists the tags of a repository.
+func (c *Client) Tags(ctx context.Context, ref Reference) ([]string, error) {
+	resp, err := c.do(ctx, ref.Registry, "repository:"+ref.Repository+":pull", http.MethodGet, c.endpoint(ref, "tags/list"), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("chart %s not found", ref)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("list tags", resp)
+	}
+	var out struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode tags: %w", err)
+	}
+	return out.Tags, nil
+}
+
+func (c *Client) resolveTag(ctx context.Context, ref Reference, version string) (string, error) {
+	var constraint *semver.Constraints
+	if version != "" {
+		parsed, err := semver.NewConstraint(version)
+		if err != nil {
+			return "", fmt.Errorf("invalid version range %q: %w", version, err)
+		}
+		constraint = parsed
+	}
+
+	tags, err := c.Tags(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	var (
+		best    string
+		bestVer *semver.Version
+	)
+	for _, tag := range tags {
+		v, err := semver.NewVersion(strings.ReplaceAll(tag, "_", "+"))
+		if err != nil {
+			continue
+		}
+		if constraint != nil && !constraint.Check(v) {
+			continue
+		}
+		if bestVer == nil || v.GreaterThan(bestVer) {
+			best, bestVer = tag, v
+		}
+	}
+	if best == "" {
+		if version != "" {
+			return "", fmt.Errorf("no version of %s satisfies %q", ref, version)
+		}
+		return "", fmt.Errorf("no semver tags found for %s", ref)
+	}
+	return best, nil
+}
+
+func (c *Client) pushBlob(ctx context.Context, ref Reference, scope string, desc Descriptor, data []byte) error {
+	head, err := c.do(ctx, ref.Registry, scope, http.MethodHead, c.endpoint(ref, "blobs/"+desc.Digest), "", nil)
+	if err != nil {
+		return err
+	}
+	head.Body.Close()
+	if head.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	start, err := c.do(ctx, ref.Registry, scope, http.MethodPost, c.endpoint(ref, "blobs/uploads/"), "", nil)
+	if err != nil {
+		return err
+	}
+	start.Body.Close()
+	if start.StatusCode != http.StatusAccepted {
+		return statusError("start blob upload", start)
+	}
+	location, err := start.Request.URL.Parse(start.Header.Get("Location"))
+	if err != nil || start.Header.Get("Location") == "" {
+		return fmt.Errorf("start blob upload: registry returned no upload location")
+	}
+	query := location.Query()
+	query.Set("digest", desc.Digest)
+	location.RawQuery = query.Encode()
+
+	put, err := c.do(ctx, ref.Registry, scope, http.MethodPut, location.String(), "application/octet-stream", data)
+	if err != nil {
+		return err
+	}
+	put.Body.Close()
+	if put.StatusCode != http.StatusCreated {
+		return statusError("upload blob", put)
+	}
+	return nil
+}
+
+func (c *Client) endpoint(ref Reference, path string) string {
+	scheme := "https"
+	if c.PlainHTTP || isLoopback(ref.Registry) {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, ref.Registry, ref.Repository, path)
+}
+
+// do sends a request, answering Basic and Bearer token challenges with stored credentials.
+func (c *Client) do(ctx context.Context, registry, scope, method, target, contentType string, body []byte, headers ...string) (*http.Response, error) {
+	if c.Offline {
+		return nil, fmt.Errorf("%s: %w", registry, ErrOffline)
+	}
+	send := func(authorization string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("build request: %w", err)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		client, err := c.httpClient(req.URL)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", method, target, err)
+		}
+		return resp, nil
+	}
+
+	key := registry + "|" + scope
+	c.mu.Lock()
+	cached := c.tokens[key]
+	c.mu.Unlock()
+
+	resp, err := send(cached)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	authorization, err := c.authorize(ctx, registry, scope, challenge)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.tokens == nil {
+		c.tokens = map[string]string{}
+	}
+	c.tokens[key] = authorization
+	c.mu.Unlock()
+	return send(authorization)
+}
+
+func (c *Client) authorize(ctx context.Context, registry, scope, challenge string) (string, error) {
+	var creds *Credentials
+	if c.Credentials != nil {
+		found, err := c.Credentials.Get(ctx, registry)
+		if err != nil {
+			return "", err
+		}
+		creds = found
+	}
+
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if creds == nil {
+			return "", fmt.Errorf("registry %s requires authentication (docker login %s)", registry, registry)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(creds.Username, creds.Secret)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		return c.fetchToken(ctx, params, scope, creds)
+	default:
+		return "", fmt.Errorf("registry %s: unsupported authentication challenge %q", registry, challenge)
+	}
+}
+
+func (c *Client) fetchToken(ctx context.Context, params map[string]string, scope string, creds *Credentials) (string, error) {
+	realm := params["realm"]
+	if realm == "" {
+		return "", errors.New("bearer challenge without realm")
+	}
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("parse token realm: %w", err)
+	}
+	query := tokenURL.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if s := params["scope"]; s != "" {
+		scope = s
+	}
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("build token request: %w", err)
+	}
+	if creds != nil {
+		req.SetBasicAuth(creds.Username, creds.Secret)
+	}
+	client, err := c.httpClient(req.URL)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError("fetch registry token", resp)
+	}
+	var out struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode registry token: %w", err)
+	}
+	token := out.Token
+	if token == "" {
+		token = out.AccessToken
+	}
+	if token == "" {
+		return "", errors.New("registry token response did not include a token")
+	}
+	return "Bearer " + token, nil
+}
+
+// httpClient picks the client for a request: the download client's for target's host when
+// configured, so registries get the same timeout and TLS settings as chart downloads.
+func (c *Client) httpClient(target *url.URL) (*http.Client, error) {
+	if c.Downloads != nil {
+		return c.Downloads.HTTPClient(target)
+	}
+	if c.HTTPClient != nil {
+		return c.HTTPClient, nil
+	}
+	return defaultHTTPClient, nil
+}
+
+// parseChallenge splits `Bearer realm="...",service="..."` into scheme and parameters.
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var pair string
+		rest = strings.TrimLeft(rest, " ,")
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				break
+			}
+			pair, rest = value[1:end+1], value[end+2:]
+		} else {
+			pair, rest, _ = strings.Cut(value, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = pair
+	}
+	return scheme, params
+}
+
+func descriptorFor(mediaType string, data []byte) Descriptor {
+	return Descriptor{MediaType: mediaType, Digest: digestOf(data), Size: int64(len(data))}
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func statusError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	msg := strings.TrimSpace(string(body))
+	if msg != "" {
+		return fmt.Errorf("%s: unexpected status %s: %s", action, resp.Status, msg)
+	}
+	return fmt.Errorf("%s: unexpected status %s", action, resp.Status)
+}
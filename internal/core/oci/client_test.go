@@ -0,0 +1,79 @@
+package oci_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/core/oci"
+	"composepack/internal/core/oci/ocitest"
+	"composepack/internal/infra/config"
+	"composepack/internal/infra/download"
+)
+
+type staticCredentials map[string]oci.Credentials
+
+func (s staticCredentials) Get(_ context.Context, registry string) (*oci.Credentials, error) {
+	if creds, ok := s[registry]; ok {
+		return &creds, nil
+	}
+	return nil, nil
+}
+
+func TestPushPullRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	registry := ocitest.NewRegistry()
+	registry.Username, registry.Password = "ci", "s3cret"
+	server := ocitest.NewServer(registry)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	client := oci.NewClient(download.New(config.Config{}))
+	client.Credentials = staticCredentials{host: {Username: "ci", Secret: "s3cret"}}
+
+	archive := []byte("chart archive bytes")
+	provenance := []byte("signature")
+	pushed, err := client.Push(ctx, oci.Scheme+host+"/charts", oci.PushOptions{
+		Name:       "demo",
+		Version:    "1.2.0+build.1",
+		ChartYAML:  []byte("apiVersion: v2\nname: demo\nversion: 1.2.0+build.1\n"),
+		Archive:    archive,
+		Provenance: provenance,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pushed.Ref.Tag != "1.2.0_build.1" {
+		t.Fatalf("pushed tag %q, want build metadata encoded with '_'", pushed.Ref.Tag)
+	}
+
+	path, cleanup, err := client.FetchChart(ctx, oci.Scheme+host+"/charts/demo", "^1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if got, err := os.ReadFile(path); err != nil || !bytes.Equal(got, archive) {
+		t.Fatalf("pulled archive = %q, %v; want %q", got, err, archive)
+	}
+	if got, err := os.ReadFile(path + chart.ProvenanceSuffix); err != nil || !bytes.Equal(got, provenance) {
+		t.Fatalf("pulled provenance = %q, %v; want %q", got, err, provenance)
+	}
+}
+
+func TestPullRequiresCredentials(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	registry.Username, registry.Password = "ci", "s3cret"
+	server := ocitest.NewServer(registry)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	client := oci.NewClient(download.New(config.Config{}))
+	client.Credentials = staticCredentials{}
+	_, _, err := client.FetchChart(context.Background(), oci.Scheme+host+"/charts/demo:1.0.0", "")
+	if err == nil || !strings.Contains(err.Error(), "requires authentication") {
+		t.Fatalf("FetchChart() error = %v, want an authentication error", err)
+	}
+}
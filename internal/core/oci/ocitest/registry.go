@@ -0,0 +1,208 @@
+// Package ocitest provides an in-memory OCI distribution registry for exercising the oci
+// client without a real registry, in the spirit of net/http/httptest.
+package ocitest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry is a minimal in-memory implementation of the OCI distribution API: blob
+// uploads (monolithic), manifests by tag or digest, and tag listing.
+type Registry struct {
+	// Username and Password, when set, require HTTP basic auth on every request.
+	Username string
+	Password string
+
+	mu        sync.Mutex
+	blobs     map[string][]byte            // digest -> content
+	manifests map[string]map[string][]byte // repository -> tag/digest -> manifest
+	uploads   map[string]string            // upload id -> repository
+	nextID    int
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		blobs:     map[string][]byte{},
+		manifests: map[string]map[string][]byte{},
+		uploads:   map[string]string{},
+	}
+}
+
+// NewServer starts the registry on a loopback listener. The oci client talks plain HTTP to
+// loopback hosts, so `oci://<server host>/...` references work as-is.
+func NewServer(r *Registry) *httptest.Server {
+	return httptest.NewServer(r)
+}
+
+// ServeHTTP implements http.Handler.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.Username != "" {
+		user, pass, ok := req.BasicAuth()
+		if !ok || user != r.Username || pass != r.Password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="ocitest"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	if path == "" || path == req.URL.Path {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case strings.Contains(path, "/blobs/uploads/"):
+		repo, id, _ := strings.Cut(path, "/blobs/uploads/")
+		r.handleUpload(w, req, repo, id)
+	case strings.Contains(path, "/blobs/"):
+		_, digest, _ := strings.Cut(path, "/blobs/")
+		data, ok := r.blobs[digest]
+		if !ok {
+			http.Error(w, "blob unknown", http.StatusNotFound)
+			return
+		}
+		writeContent(w, req, "application/octet-stream", data)
+	case strings.Contains(path, "/manifests/"):
+		repo, ref, _ := strings.Cut(path, "/manifests/")
+		r.handleManifest(w, req, repo, ref)
+	case strings.HasSuffix(path, "/tags/list"):
+		repo := strings.TrimSuffix(path, "/tags/list")
+		r.handleTags(w, repo)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (r *Registry) handleUpload(w http.ResponseWriter, req *http.Request, repo, id string) {
+	switch req.Method {
+	case http.MethodPost:
+		r.nextID++
+		id := fmt.Sprintf("upload-%d", r.nextID)
+		r.uploads[id] = repo
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", repo, id))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		if _, ok := r.uploads[id]; !ok {
+			http.Error(w, "upload unknown", http.StatusNotFound)
+			return
+		}
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		digest := req.URL.Query().Get("digest")
+		if digest != digestOf(data) {
+			http.Error(w, "digest invalid", http.StatusBadRequest)
+			return
+		}
+		delete(r.uploads, id)
+		r.blobs[digest] = data
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) handleManifest(w http.ResponseWriter, req *http.Request, repo, ref string) {
+	switch req.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var manifest struct {
+			Config struct {
+				Digest string `json:"digest"`
+			} `json:"config"`
+			Layers []struct {
+				Digest string `json:"digest"`
+			} `json:"layers"`
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			http.Error(w, "manifest invalid", http.StatusBadRequest)
+			return
+		}
+		for _, digest := range append([]string{manifest.Config.Digest}, layerDigests(manifest.Layers)...) {
+			if _, ok := r.blobs[digest]; !ok {
+				http.Error(w, "blob unknown: "+digest, http.StatusBadRequest)
+				return
+			}
+		}
+		if r.manifests[repo] == nil {
+			r.manifests[repo] = map[string][]byte{}
+		}
+		digest := digestOf(data)
+		r.manifests[repo][ref] = data
+		r.manifests[repo][digest] = data
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet, http.MethodHead:
+		data, ok := r.manifests[repo][ref]
+		if !ok {
+			http.Error(w, "manifest unknown", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", digestOf(data))
+		writeContent(w, req, "application/vnd.oci.image.manifest.v1+json", data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) handleTags(w http.ResponseWriter, repo string) {
+	manifests, ok := r.manifests[repo]
+	if !ok {
+		http.Error(w, "name unknown", http.StatusNotFound)
+		return
+	}
+	tags := []string{}
+	for ref := range manifests {
+		if !strings.HasPrefix(ref, "sha256:") {
+			tags = append(tags, ref)
+		}
+	}
+	sort.Strings(tags)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"name": repo, "tags": tags})
+}
+
+func layerDigests(layers []struct {
+	Digest string `json:"digest"`
+}) []string {
+	out := make([]string, 0, len(layers))
+	for _, l := range layers {
+		out = append(out, l.Digest)
+	}
+	return out
+}
+
+func writeContent(w http.ResponseWriter, req *http.Request, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
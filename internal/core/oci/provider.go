@@ -0,0 +1,13 @@
+package oci
+
+import (
+	"github.com/google/wire"
+
+	"composepack/internal/core/chart"
+)
+
+// ProviderSet exposes the registry client wiring for DI.
+var ProviderSet = wire.NewSet(
+	NewClient,
+	wire.Bind(new(chart.OCIFetcher), new(*Client)),
+)
@@ -0,0 +1,64 @@
+package oci
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Scheme prefixes chart sources stored in OCI registries.
+const Scheme = "oci://"
+
+// Reference identifies a repository (and optionally a tag) in a registry.
+type Reference struct {
+	Registry   string // host[:port]
+	Repository string // path inside the registry, e.g. team/charts/postgres
+	Tag        string
+}
+
+// ParseReference parses `oci://host[:port]/path[:tag]`.
+func ParseReference(ref string) (Reference, error) {
+	if !strings.HasPrefix(ref, Scheme) {
+		return Reference{}, fmt.Errorf("invalid OCI reference %q (must start with %s)", ref, Scheme)
+	}
+	rest := strings.TrimPrefix(ref, Scheme)
+	host, path, found := strings.Cut(rest, "/")
+	if !found || host == "" || path == "" {
+		return Reference{}, fmt.Errorf("invalid OCI reference %q (expected %shost/repository)", ref, Scheme)
+	}
+
+	var tag string
+	if i := strings.LastIndex(path, ":"); i > strings.LastIndex(path, "/") {
+		path, tag = path[:i], path[i+1:]
+		if tag == "" {
+			return Reference{}, fmt.Errorf("invalid OCI reference %q (empty tag)", ref)
+		}
+	}
+	path = strings.Trim(path, "/")
+	if path == "" || strings.ToLower(path) != path {
+		return Reference{}, fmt.Errorf("invalid OCI reference %q (repository must be lowercase)", ref)
+	}
+	return Reference{Registry: host, Repository: path, Tag: tag}, nil
+}
+
+// String renders the reference back to its oci:// form.
+func (r Reference) String() string {
+	s := Scheme + r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	return s
+}
+
+// isLoopback reports whether the registry host is served locally, where plain HTTP is assumed.
+func isLoopback(registry string) bool {
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,247 @@
+// Package provenance signs chart archives with Ed25519 keys and verifies the detached
+// `<archive>.prov` files produced by `composepack package --sign`.
+package provenance
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/util/fsutil"
+)
+
+const (
+	// FileSuffix is appended to an archive path to locate its provenance file.
+	FileSuffix = chart.ProvenanceSuffix
+	// Algorithm is the only supported signature algorithm.
+	Algorithm = "ed25519"
+)
+
+// ErrVerification is matched (via errors.Is) by every failed verification.
+var ErrVerification = errors.New("chart verification failed")
+
+// Statement is the signed content of a provenance file.
+type Statement struct {
+	Archive string    `json:"archive"` // file name of the signed archive
+	Digest  string    `json:"digest"`  // sha256:<hex> of the archive
+	Chart   string    `json:"chart"`   // Chart.yaml as packaged
+	Created time.Time `json:"created"`
+}
+
+// File is the on-disk provenance document. The signature covers the exact bytes of Payload,
+// so the statement never needs to be re-serialised canonically.
+type File struct {
+	Payload   string    `json:"payload"` // YAML-encoded Statement
+	Signature Signature `json:"signature"`
+}
+
+// Signature identifies the signing key and carries the base64 signature.
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyID"`
+	Value     string `json:"value"`
+}
+
+// Verification describes a successfully verified archive.
+type Verification struct {
+	Statement
+	KeyID string
+}
+
+// Path returns the provenance file location for an archive.
+func Path(archive string) string {
+	return archive + FileSuffix
+}
+
+// KeyID fingerprints a public key as SHA256:<base64>, like ssh-keygen -l.
+func KeyID(pub ed25519.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// Sign writes `<archive>.prov` for a packaged chart and returns its path.
+func Sign(ctx context.Context, archive string, key ed25519.PrivateKey) (string, *Verification, error) {
+	digest, err := chart.FileDigest(archive)
+	if err != nil {
+		return "", nil, err
+	}
+	chartYAML, _, err := chart.ReadArchiveMetadata(archive)
+	if err != nil {
+		return "", nil, err
+	}
+	statement := Statement{
+		Archive: filepath.Base(archive),
+		Digest:  digest,
+		Chart:   string(chartYAML),
+		Created: time.Now().UTC().Truncate(time.Second),
+	}
+	payload, err := yaml.Marshal(statement)
+	if err != nil {
+		return "", nil, fmt.Errorf("encode provenance: %w", err)
+	}
+
+	keyID := KeyID(key.Public().(ed25519.PublicKey))
+	data, err := yaml.Marshal(File{
+		Payload: string(payload),
+		Signature: Signature{
+			Algorithm: Algorithm,
+			KeyID:     keyID,
+			Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+		},
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("encode provenance: %w", err)
+	}
+
+	path := Path(archive)
+	if err := fsutil.WriteFileAtomic(ctx, path, data, 0o644); err != nil {
+		return "", nil, fmt.Errorf("write provenance: %w", err)
+	}
+	return path, &Verification{Statement: statement, KeyID: keyID}, nil
+}
+
+// Keyring holds the public keys trusted to sign charts.
+type Keyring struct {
+	keys map[string]ed25519.PublicKey // by KeyID
+}
+
+// LoadKeyring reads PEM-encoded Ed25519 keys ("PUBLIC KEY", or "PRIVATE KEY" whose public
+// half is used) from a file.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("keyring %s not found (pass --keyring)", path)
+		}
+		return nil, fmt.Errorf("read keyring: %w", err)
+	}
+	ring := &Keyring{keys: map[string]ed25519.PublicKey{}}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		var pub ed25519.PublicKey
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("keyring %s: %w", path, err)
+			}
+			ed, ok := key.(ed25519.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("keyring %s: only Ed25519 keys are supported", path)
+			}
+			pub = ed
+		case "PRIVATE KEY":
+			key, err := parsePrivateKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("keyring %s: %w", path, err)
+			}
+			pub = key.Public().(ed25519.PublicKey)
+		default:
+			continue
+		}
+		ring.keys[KeyID(pub)] = pub
+	}
+	if len(ring.keys) == 0 {
+		return nil, fmt.Errorf("keyring %s contains no Ed25519 PEM keys", path)
+	}
+	return ring, nil
+}
+
+// KeyIDs lists the fingerprints of the trusted keys.
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Verify implements chart.Verifier.
+func (k *Keyring) Verify(archive, provenancePath string) error {
+	_, err := k.VerifyFile(archive, provenancePath)
+	return err
+}
+
+// VerifyFile checks the provenance signature against the keyring and the archive against
+// the signed digest.
+func (k *Keyring) VerifyFile(archive, provenancePath string) (*Verification, error) {
+	data, err := os.ReadFile(provenancePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s is not signed (no %s file)", ErrVerification, filepath.Base(archive), FileSuffix)
+		}
+		return nil, fmt.Errorf("read provenance: %w", err)
+	}
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: parse provenance: %v", ErrVerification, err)
+	}
+	if file.Signature.Algorithm != Algorithm {
+		return nil, fmt.Errorf("%w: unsupported signature algorithm %q", ErrVerification, file.Signature.Algorithm)
+	}
+	pub, ok := k.keys[file.Signature.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: signed by unknown key %s", ErrVerification, file.Signature.KeyID)
+	}
+	signature, err := base64.StdEncoding.DecodeString(file.Signature.Value)
+	if err != nil || !ed25519.Verify(pub, []byte(file.Payload), signature) {
+		return nil, fmt.Errorf("%w: invalid signature from key %s", ErrVerification, file.Signature.KeyID)
+	}
+
+	var statement Statement
+	if err := yaml.Unmarshal([]byte(file.Payload), &statement); err != nil {
+		return nil, fmt.Errorf("%w: parse signed statement: %v", ErrVerification, err)
+	}
+	if err := chart.VerifyDigest(archive, statement.Digest); err != nil {
+		return nil, fmt.Errorf("%w: %s does not match its signature: %v", ErrVerification, filepath.Base(archive), err)
+	}
+	return &Verification{Statement: statement, KeyID: file.Signature.KeyID}, nil
+}
+
+// LoadPrivateKey reads a PEM "PRIVATE KEY" (PKCS#8) Ed25519 key, as written by
+// `openssl genpkey -algorithm ed25519`.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read signing key: %w", err)
+	}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "PRIVATE KEY" {
+			key, err := parsePrivateKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("signing key %s: %w", path, err)
+			}
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("signing key %s: no PEM \"PRIVATE KEY\" block", path)
+}
+
+func parsePrivateKey(der []byte) (ed25519.PrivateKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	ed, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("only Ed25519 keys are supported")
+	}
+	return ed, nil
+}
@@ -1,7 +1,6 @@
 package release
 
 import (
-	"composepack/internal/core/chart"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -11,22 +10,47 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"time"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/core/hooks"
+	"composepack/internal/core/values"
 )
 
-const metadataFileName = "release.json"
+const (
+	metadataFileName = "release.json"
+	historyDirName   = "history"
+)
 
 // Metadata captures release.json contents in runtime directories.
 type Metadata struct {
-	ReleaseName   string              `json:"releaseName"`
-	ChartMetadata chart.ChartMetadata `json:"chartMetadata"`
-	ChartSource   string              `json:"chartSource,omitempty"`
-	ChartDigest   string              `json:"chartDigest"`
-	RuntimePath   string              `json:"runtimePath"`
-	CreatedAt     time.Time           `json:"createdAt"`
-	Values        map[string]any      `json:"values,omitempty"`
-	ValuesSources []string            `json:"valuesSources"`
-	ComposeFiles  []string            `json:"composeFiles"`
+	ReleaseName   string                       `json:"releaseName"`
+	Revision      int                          `json:"revision"`
+	ChartMetadata chart.ChartMetadata          `json:"chartMetadata"`
+	ChartSource   string                       `json:"chartSource,omitempty"`
+	ChartDigest   string                       `json:"chartDigest"`
+	SourceDigest  string                       `json:"sourceDigest,omitempty"` // sha256:<hex> of the chart archive rendered
+	RuntimePath   string                       `json:"runtimePath"`
+	CreatedAt     time.Time                    `json:"createdAt"`
+	Values        map[string]any               `json:"values,omitempty"`
+	UserValues    map[string]any               `json:"userValues,omitempty"`
+	SecretPaths   map[string]values.SecretMode `json:"secretPaths,omitempty"`
+	ValuesSources []string                     `json:"valuesSources"`
+	Env           map[string]string            `json:"env,omitempty"`
+	ComposeFiles  []string                     `json:"composeFiles"`
+	Images        []ImageDigest                `json:"images,omitempty"` // set when rendered with --pin-digests
+	Hooks         []hooks.Hook                 `json:"hooks,omitempty"`
+	HookRuns      []hooks.Result               `json:"hookRuns,omitempty"` // hooks run for this revision, in order
+	Deployed      bool                         `json:"deployed,omitempty"` // containers were started at least once
+}
+
+// ImageDigest records the digest a service image was pinned to.
+type ImageDigest struct {
+	Service string `json:"service"`
+	Image   string `json:"image"` // reference as rendered, usually name:tag
+	Digest  string `json:"digest"`
 }
 
 // Store persists release metadata inside runtime directories.
@@ -59,7 +83,8 @@ func (s *Store) Load(ctx context.Context, runtimePath string) (*Metadata, error)
 	return &meta, nil
 }
 
-// Save writes release metadata to `<runtime>/release.json`.
+// Save writes release metadata to `<runtime>/release.json` and snapshots it under
+// `<runtime>/history/<revision>/`. Secret-marked values are sealed before they hit disk.
 func (s *Store) Save(ctx context.Context, runtimePath string, meta *Metadata) error {
 	if runtimePath == "" {
 		return errors.New("runtime path is required")
@@ -73,6 +98,17 @@ func (s *Store) Save(ctx context.Context, runtimePath string, meta *Metadata) er
 		}
 	}
 
+	if meta.Revision == 0 {
+		current, err := s.Load(ctx, runtimePath)
+		if err != nil {
+			return err
+		}
+		meta.Revision = 1
+		if current != nil {
+			meta.Revision = current.Revision + 1
+		}
+	}
+
 	meta.RuntimePath = runtimePath
 	if meta.CreatedAt.IsZero() {
 		meta.CreatedAt = time.Now().UTC()
@@ -83,29 +119,133 @@ func (s *Store) Save(ctx context.Context, runtimePath string, meta *Metadata) er
 		return fmt.Errorf("ensure runtime directory: %w", err)
 	}
 
-	// hide confidential fields from the metadata
-	val := meta.Values
-	meta.Values = nil
-	defer func() {
-		meta.Values = val
-	}()
+	stored := *meta
+	var err error
+	if stored.Values, err = s.seal(runtimePath, meta.Values, meta.SecretPaths); err != nil {
+		return fmt.Errorf("seal values: %w", err)
+	}
+	if stored.UserValues, err = s.seal(runtimePath, meta.UserValues, meta.SecretPaths); err != nil {
+		return fmt.Errorf("seal user values: %w", err)
+	}
 
-	data, err := json.MarshalIndent(meta, "", "  ")
+	data, err := json.MarshalIndent(&stored, "", "  ")
 	if err != nil {
 		return fmt.Errorf("serialize metadata: %w", err)
 	}
 
-	tempPath := filepath.Join(runtimePath, ".release.json.tmp")
-	if err := os.WriteFile(tempPath, data, 0o644); err != nil {
-		return fmt.Errorf("write temp metadata: %w", err)
+	revisionDir := s.revisionDir(runtimePath, meta.Revision)
+	if err := os.MkdirAll(revisionDir, 0o755); err != nil {
+		return fmt.Errorf("ensure history directory: %w", err)
 	}
-	if err := os.Rename(tempPath, filepath.Join(runtimePath, metadataFileName)); err != nil {
-		return fmt.Errorf("rename metadata file: %w", err)
+	if err := writeAtomic(revisionDir, metadataFileName, data); err != nil {
+		return err
 	}
+	return writeAtomic(runtimePath, metadataFileName, data)
+}
+
+// LoadRevision reads the snapshot of a specific revision. A zero revision returns the current release.
+func (s *Store) LoadRevision(ctx context.Context, runtimePath string, revision int) (*Metadata, error) {
+	if revision == 0 {
+		return s.Load(ctx, runtimePath)
+	}
+	return s.Load(ctx, s.revisionDir(runtimePath, revision))
+}
 
+// History returns every recorded revision, oldest first.
+func (s *Store) History(ctx context.Context, runtimePath string) ([]*Metadata, error) {
+	if runtimePath == "" {
+		return nil, errors.New("runtime path is required")
+	}
+	entries, err := os.ReadDir(filepath.Join(runtimePath, historyDirName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read release history: %w", err)
+	}
+
+	var revisions []int
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		rev, err := strconv.Atoi(entry.Name())
+		if err != nil || rev <= 0 {
+			continue
+		}
+		revisions = append(revisions, rev)
+	}
+	sort.Ints(revisions)
+
+	out := make([]*Metadata, 0, len(revisions))
+	for _, rev := range revisions {
+		meta, err := s.LoadRevision(ctx, runtimePath, rev)
+		if err != nil {
+			return nil, err
+		}
+		if meta != nil {
+			out = append(out, meta)
+		}
+	}
+	return out, nil
+}
+
+// SaveArtifact stores a rendered artifact (compose file, notes, ...) alongside a revision snapshot.
+func (s *Store) SaveArtifact(ctx context.Context, runtimePath string, revision int, name string, data []byte) error {
+	if runtimePath == "" {
+		return errors.New("runtime path is required")
+	}
+	if revision <= 0 {
+		return fmt.Errorf("invalid revision %d", revision)
+	}
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	dir := s.revisionDir(runtimePath, revision)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("ensure history directory: %w", err)
+	}
+	return writeAtomic(dir, name, data)
+}
+
+// LoadArtifact reads an artifact stored for a revision, returning (nil, nil) when it is missing.
+func (s *Store) LoadArtifact(ctx context.Context, runtimePath string, revision int, name string) ([]byte, error) {
+	if runtimePath == "" {
+		return nil, errors.New("runtime path is required")
+	}
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(s.revisionDir(runtimePath, revision), name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read %s for revision %d: %w", name, revision, err)
+	}
+	return data, nil
+}
+
+func (s *Store) revisionDir(runtimePath string, revision int) string {
+	return filepath.Join(runtimePath, historyDirName, strconv.Itoa(revision))
+}
+
+func writeAtomic(dir, name string, data []byte) error {
+	tempPath := filepath.Join(dir, "."+name+".tmp")
+	if err := os.WriteFile(tempPath, data, 0o644); err != nil {
+		return fmt.Errorf("write temp %s: %w", name, err)
+	}
+	if err := os.Rename(tempPath, filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("rename %s: %w", name, err)
+	}
 	return nil
 }
 
+// Digest derives ChartDigest from the identifying release fields.
 func (m *Metadata) Digest() {
 	fieldsToBeHashed := []string{
 		m.ReleaseName,
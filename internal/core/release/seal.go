@@ -0,0 +1,137 @@
+package release
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"composepack/internal/core/values"
+)
+
+const (
+	keyFileName    = ".release.key"
+	sealedPrefix   = "enc:v1:"
+	redactedMarker = "<redacted>"
+)
+
+// ErrKeyUnavailable is returned when sealed values cannot be opened because the release key is missing.
+var ErrKeyUnavailable = errors.New("release key is not available")
+
+// seal returns a copy of vals with secret paths encrypted (or redacted) using the release key.
+func (s *Store) seal(runtimePath string, vals map[string]any, secrets map[string]values.SecretMode) (map[string]any, error) {
+	if len(vals) == 0 || len(secrets) == 0 {
+		return vals, nil
+	}
+
+	var aead cipher.AEAD
+	return values.TransformPaths(vals, secrets, func(path string, mode values.SecretMode, val any) (any, error) {
+		if mode == values.SecretRedact {
+			return redactedMarker, nil
+		}
+		if str, ok := val.(string); ok && strings.HasPrefix(str, sealedPrefix) {
+			return str, nil
+		}
+		if aead == nil {
+			var err error
+			if aead, err = s.releaseCipher(runtimePath, true); err != nil {
+				return nil, err
+			}
+		}
+		plain, err := json.Marshal(val)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %w", path, err)
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, fmt.Errorf("generate nonce: %w", err)
+		}
+		sealed := aead.Seal(nonce, nonce, plain, []byte(path))
+		return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+	})
+}
+
+// Unseal decrypts sealed secret values using the release key stored in the runtime directory.
+// Redacted values stay redacted; ErrKeyUnavailable is returned when the key file is missing.
+func (s *Store) Unseal(runtimePath string, vals map[string]any, secrets map[string]values.SecretMode) (map[string]any, error) {
+	if len(vals) == 0 || len(secrets) == 0 {
+		return vals, nil
+	}
+
+	var aead cipher.AEAD
+	return values.TransformPaths(vals, secrets, func(path string, mode values.SecretMode, val any) (any, error) {
+		str, ok := val.(string)
+		if !ok || !strings.HasPrefix(str, sealedPrefix) {
+			return val, nil
+		}
+		if aead == nil {
+			var err error
+			if aead, err = s.releaseCipher(runtimePath, false); err != nil {
+				return nil, err
+			}
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(str, sealedPrefix))
+		if err != nil || len(raw) < aead.NonceSize() {
+			return nil, fmt.Errorf("decode sealed value at %s", path)
+		}
+		plain, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], []byte(path))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt %s: %w", path, err)
+		}
+		var out any
+		if err := json.Unmarshal(plain, &out); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+		return out, nil
+	})
+}
+
+func (s *Store) releaseCipher(runtimePath string, create bool) (cipher.AEAD, error) {
+	key, err := loadOrCreateKey(filepath.Join(runtimePath, keyFileName), create)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func loadOrCreateKey(path string, create bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, decodeErr := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if decodeErr != nil || len(key) != 32 {
+			return nil, fmt.Errorf("release key %s is malformed", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read release key: %w", err)
+	}
+	if !create {
+		return nil, ErrKeyUnavailable
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("generate release key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("ensure runtime directory: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := os.WriteFile(path, []byte(encoded), 0o600); err != nil {
+		return nil, fmt.Errorf("write release key: %w", err)
+	}
+	return key, nil
+}
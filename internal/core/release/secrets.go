@@ -0,0 +1,152 @@
+package release
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"composepack/internal/util/fsutil"
+)
+
+const (
+	secretsFileName = ".secrets.json"
+
+	// MaxSecretLength bounds genSecret values.
+	MaxSecretLength = 1024
+	secretAlphabet  = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// Secrets holds the values generated by the genSecret template function for one release.
+// They live in a 0600 file in the runtime directory so re-renders reuse them; the zero
+// value is an in-memory store (used when linting).
+type Secrets struct {
+	path    string
+	values  map[string]string
+	changed bool
+}
+
+// LoadSecrets opens the secret store of the release at runtimePath. A missing store is empty.
+func (s *Store) LoadSecrets(runtimePath string) (*Secrets, error) {
+	if runtimePath == "" {
+		return nil, errors.New("runtime path is required")
+	}
+	path := filepath.Join(runtimePath, secretsFileName)
+	sec := &Secrets{path: path, values: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return sec, nil
+		}
+		return nil, fmt.Errorf("read secret store: %w", err)
+	}
+	if err := json.Unmarshal(data, &sec.values); err != nil {
+		return nil, fmt.Errorf("parse secret store %s: %w", path, err)
+	}
+	return sec, nil
+}
+
+// Secret returns the value stored under name, generating an alphanumeric value of length
+// characters the first time. Stored values are returned as is, whatever length is asked for.
+func (sec *Secrets) Secret(name string, length int) (string, error) {
+	if value, ok := sec.Value(name); ok {
+		return value, nil
+	}
+	if err := CheckSecret(name, length); err != nil {
+		return "", err
+	}
+	value, err := randomSecret(length)
+	if err != nil {
+		return "", err
+	}
+	if sec.values == nil {
+		sec.values = map[string]string{}
+	}
+	sec.values[name] = value
+	sec.changed = true
+	return value, nil
+}
+
+// Value returns the stored value of a secret without generating it.
+func (sec *Secrets) Value(name string) (string, bool) {
+	value, ok := sec.values[name]
+	return value, ok
+}
+
+// CheckSecret validates the arguments of a genSecret call.
+func CheckSecret(name string, length int) error {
+	if name == "" {
+		return errors.New("secret name is required")
+	}
+	if length < 1 || length > MaxSecretLength {
+		return fmt.Errorf("secret %s: length must be between 1 and %d, got %d", name, MaxSecretLength, length)
+	}
+	return nil
+}
+
+// Names lists the stored secrets, sorted.
+func (sec *Secrets) Names() []string {
+	names := make([]string, 0, len(sec.values))
+	for name := range sec.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rotate replaces the named secrets with new values of the same length.
+func (sec *Secrets) Rotate(names ...string) error {
+	for _, name := range names {
+		if _, ok := sec.values[name]; !ok {
+			return fmt.Errorf("secret %s not found", name)
+		}
+	}
+	for _, name := range names {
+		value, err := randomSecret(len(sec.values[name]))
+		if err != nil {
+			return err
+		}
+		sec.values[name] = value
+		sec.changed = true
+	}
+	return nil
+}
+
+// Save writes the store when values were generated or rotated since it was loaded.
+func (sec *Secrets) Save(ctx context.Context) error {
+	if !sec.changed {
+		return nil
+	}
+	if sec.path == "" {
+		return errors.New("secret store has no runtime directory")
+	}
+	data, err := json.MarshalIndent(sec.values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode secret store: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(ctx, sec.path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write secret store: %w", err)
+	}
+	sec.changed = false
+	return nil
+}
+
+func randomSecret(length int) (string, error) {
+	max := big.NewInt(int64(len(secretAlphabet)))
+	out := make([]byte, length)
+	for i := range out {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("generate secret: %w", err)
+		}
+		out[i] = secretAlphabet[n.Int64()]
+	}
+	return string(out), nil
+}
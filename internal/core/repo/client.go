@@ -0,0 +1,269 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"composepack/internal/infra/config"
+	"composepack/internal/infra/download"
+	"composepack/internal/util/fsutil"
+)
+
+// Client manages configured repositories and their cached indexes.
+type Client struct {
+	RepositoryFile string // path to repositories.yaml
+	CacheDir       string // directory holding <name>-index.yaml files
+	HTTP           *download.Client
+}
+
+// NewClient builds a repository client from the process configuration.
+func NewClient(cfg config.Config, http *download.Client) *Client {
+	return &Client{RepositoryFile: cfg.RepositoryFile, CacheDir: cfg.RepositoryCache, HTTP: http}
+}
+
+// SearchResult is one chart version matched by Search.
+type SearchResult struct {
+	Name        string // repo/chart
+	Version     string
+	Description string
+}
+
+// List returns the configured repositories.
+func (c *Client) List() ([]*Entry, error) {
+	f, err := LoadFile(c.RepositoryFile)
+	if err != nil {
+		return nil, err
+	}
+	return f.Repositories, nil
+}
+
+// Add registers a repository after downloading its index. An existing repository with a
+// different URL is only replaced when force is set.
+func (c *Client) Add(ctx context.Context, entry *Entry, force bool) error {
+	if err := ValidateName(entry.Name); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(entry.URL, "http://") && !strings.HasPrefix(entry.URL, "https://") {
+		return fmt.Errorf("repository URL %q must be http(s)", entry.URL)
+	}
+	entry.URL = strings.TrimSuffix(entry.URL, "/")
+
+	f, err := LoadFile(c.RepositoryFile)
+	if err != nil {
+		return err
+	}
+	if existing, ok := f.Get(entry.Name); ok && existing.URL != entry.URL && !force {
+		return fmt.Errorf("repository %s already exists with URL %s (use --force-update to replace it)", entry.Name, existing.URL)
+	}
+
+	if err := c.download(ctx, entry); err != nil {
+		return err
+	}
+	f.Set(entry)
+	return f.Save(c.RepositoryFile)
+}
+
+// Remove unregisters a repository and drops its cached index.
+func (c *Client) Remove(name string) error {
+	f, err := LoadFile(c.RepositoryFile)
+	if err != nil {
+		return err
+	}
+	if !f.Remove(name) {
+		return fmt.Errorf("repository %s not found", name)
+	}
+	if err := f.Save(c.RepositoryFile); err != nil {
+		return err
+	}
+	if err := os.Remove(c.indexPath(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove cached index: %w", err)
+	}
+	return nil
+}
+
+// Update refreshes the cached index of the named repositories (all when names is empty).
+func (c *Client) Update(ctx context.Context, names ...string) ([]*Entry, error) {
+	f, err := LoadFile(c.RepositoryFile)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := f.Repositories
+	if len(names) > 0 {
+		targets = nil
+		for _, name := range names {
+			entry, ok := f.Get(name)
+			if !ok {
+				return nil, fmt.Errorf("repository %s not found", name)
+			}
+			targets = append(targets, entry)
+		}
+	}
+
+	for _, entry := range targets {
+		if err := c.download(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// Index returns the cached index of a repository, downloading it when not cached yet.
+func (c *Client) Index(ctx context.Context, name string) (*IndexFile, error) {
+	f, err := LoadFile(c.RepositoryFile)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := f.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("repository %s not found", name)
+	}
+	return c.index(ctx, entry)
+}
+
+func (c *Client) index(ctx context.Context, entry *Entry) (*IndexFile, error) {
+	idx, err := LoadIndex(c.indexPath(entry.Name))
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := c.download(ctx, entry); err != nil {
+			return nil, err
+		}
+		idx, err = LoadIndex(c.indexPath(entry.Name))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository %s: %w", entry.Name, err)
+	}
+	return idx, nil
+}
+
+// Search matches term (case-insensitive) against chart names and descriptions in every cached
+// index. Only the newest matching version of each chart is returned unless allVersions is set.
+func (c *Client) Search(ctx context.Context, term string, allVersions bool) ([]SearchResult, error) {
+	f, err := LoadFile(c.RepositoryFile)
+	if err != nil {
+		return nil, err
+	}
+
+	term = strings.ToLower(term)
+	var results []SearchResult
+	for _, entry := range f.Repositories {
+		idx, err := c.index(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+		for name, versions := range idx.Entries {
+			for _, cv := range versions {
+				ref := entry.Name + "/" + name
+				if term != "" && !strings.Contains(strings.ToLower(ref), term) && !strings.Contains(strings.ToLower(cv.Description), term) {
+					continue
+				}
+				results = append(results, SearchResult{Name: ref, Version: cv.Version, Description: cv.Description})
+				if !allVersions {
+					break
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+// ResolveChart implements chart.RepositoryResolver for `repo/chart` references.
+func (c *Client) ResolveChart(ctx context.Context, ref, version string) (string, string, bool, error) {
+	repoName, chartName, found := strings.Cut(ref, "/")
+	if !found {
+		return "", "", false, nil
+	}
+	f, err := LoadFile(c.RepositoryFile)
+	if err != nil {
+		return "", "", false, err
+	}
+	entry, ok := f.Get(repoName)
+	if !ok {
+		return "", "", false, nil
+	}
+
+	idx, err := c.index(ctx, entry)
+	if err != nil {
+		return "", "", true, err
+	}
+	chartURL, digest, err := chartDownload(entry.URL, idx, chartName, version)
+	if err != nil {
+		return "", "", true, err
+	}
+	return chartURL, digest, true, nil
+}
+
+// ResolveFromURL resolves a chart against the index of a repository URL that is not
+// registered with `repo add`. The index is fetched on every call and not cached.
+func (c *Client) ResolveFromURL(ctx context.Context, repoURL, chartName, version string) (string, string, error) {
+	_, idx, err := c.fetchIndex(ctx, repoURL)
+	if err != nil {
+		return "", "", fmt.Errorf("repository %s: %w", repoURL, err)
+	}
+	return chartDownload(repoURL, idx, chartName, version)
+}
+
+func chartDownload(repoURL string, idx *IndexFile, chartName, version string) (string, string, error) {
+	cv, err := idx.Get(chartName, version)
+	if err != nil {
+		return "", "", fmt.Errorf("repository %s: %w", repoURL, err)
+	}
+	if len(cv.URLs) == 0 {
+		return "", "", fmt.Errorf("repository %s: chart %s %s has no download URL", repoURL, chartName, cv.Version)
+	}
+	chartURL, err := ResolveURL(repoURL, cv.URLs[0])
+	if err != nil {
+		return "", "", err
+	}
+	return chartURL, cv.Digest, nil
+}
+
+// ResolveURL resolves a chart URL from an index against the repository base URL.
+func ResolveURL(base, ref string) (string, error) {
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("parse chart URL %q: %w", ref, err)
+	}
+	if parsed.IsAbs() {
+		return ref, nil
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(base, "/") + "/")
+	if err != nil {
+		return "", fmt.Errorf("parse repository URL %q: %w", base, err)
+	}
+	return baseURL.ResolveReference(parsed).String(), nil
+}
+
+func (c *Client) indexPath(name string) string {
+	return filepath.Join(c.CacheDir, name+"-"+IndexFileName)
+}
+
+// download fetches <url>/index.yaml, validates it and stores it in the cache.
+func (c *Client) download(ctx context.Context, entry *Entry) error {
+	data, _, err := c.fetchIndex(ctx, entry.URL)
+	if err != nil {
+		return fmt.Errorf("repository %s: %w", entry.Name, err)
+	}
+	return fsutil.WriteFileAtomic(ctx, c.indexPath(entry.Name), data, 0o644)
+}
+
+func (c *Client) fetchIndex(ctx context.Context, repoURL string) ([]byte, *IndexFile, error) {
+	indexURL := strings.TrimSuffix(repoURL, "/") + "/" + IndexFileName
+	data, err := c.HTTP.Get(ctx, indexURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx, err := ParseIndex(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, idx, nil
+}
@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"sigs.k8s.io/yaml"
+
+	"composepack/internal/util/fsutil"
+)
+
+// IndexFileName is the repository index served next to the chart archives.
+const IndexFileName = "index.yaml"
+
+const indexAPIVersion = "v1"
+
+// ErrChartNotFound is returned when an index has no version of a chart matching a request.
+var ErrChartNotFound = errors.New("chart not found")
+
+// ChartVersion describes one packaged chart version in an index.
+type ChartVersion struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	AppVersion  string    `json:"appVersion,omitempty"`
+	Description string    `json:"description,omitempty"`
+	URLs        []string  `json:"urls"`
+	Digest      string    `json:"digest,omitempty"` // sha256:<hex> of the archive
+	Created     time.Time `json:"created,omitempty"`
+}
+
+// IndexFile lists every chart version available in a repository.
+type IndexFile struct {
+	APIVersion string                     `json:"apiVersion"`
+	Generated  time.Time                  `json:"generated"`
+	Entries    map[string][]*ChartVersion `json:"entries"`
+}
+
+// NewIndexFile returns an empty index.
+func NewIndexFile() *IndexFile {
+	return &IndexFile{APIVersion: indexAPIVersion, Generated: time.Now().UTC(), Entries: map[string][]*ChartVersion{}}
+}
+
+// LoadIndex parses an index.yaml file.
+func LoadIndex(path string) (*IndexFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseIndex(data)
+}
+
+// ParseIndex decodes index.yaml contents.
+func ParseIndex(data []byte) (*IndexFile, error) {
+	var idx IndexFile
+	if err := yaml.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", IndexFileName, err)
+	}
+	if idx.APIVersion == "" {
+		return nil, fmt.Errorf("parse %s: missing apiVersion", IndexFileName)
+	}
+	if idx.Entries == nil {
+		idx.Entries = map[string][]*ChartVersion{}
+	}
+	idx.SortEntries()
+	return &idx, nil
+}
+
+// SortEntries orders every chart's versions from newest to oldest.
+func (i *IndexFile) SortEntries() {
+	for _, versions := range i.Entries {
+		sort.SliceStable(versions, func(a, b int) bool {
+			va, errA := semver.NewVersion(versions[a].Version)
+			vb, errB := semver.NewVersion(versions[b].Version)
+			if errA != nil || errB != nil {
+				return versions[a].Version > versions[b].Version
+			}
+			return va.GreaterThan(vb)
+		})
+	}
+}
+
+// Add appends a chart version unless the index already lists that name and version.
+// It reports whether the entry was added.
+func (i *IndexFile) Add(cv *ChartVersion) bool {
+	if i.Has(cv.Name, cv.Version) {
+		return false
+	}
+	i.Entries[cv.Name] = append(i.Entries[cv.Name], cv)
+	return true
+}
+
+// Has reports whether the index lists an exact chart version.
+func (i *IndexFile) Has(name, version string) bool {
+	for _, cv := range i.Entries[name] {
+		if cv.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteFile sorts the index and writes it to path.
+func (i *IndexFile) WriteFile(path string) error {
+	i.SortEntries()
+	data, err := yaml.Marshal(i)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", IndexFileName, err)
+	}
+	return fsutil.WriteFileAtomic(context.Background(), path, data, 0o644)
+}
+
+// Get returns the newest version of a chart satisfying the semver range (any version when empty).
+func (i *IndexFile) Get(name, versionRange string) (*ChartVersion, error) {
+	var constraint *semver.Constraints
+	if versionRange != "" {
+		c, err := semver.NewConstraint(versionRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", versionRange, err)
+		}
+		constraint = c
+	}
+
+	for _, cv := range i.Entries[name] {
+		if constraint == nil {
+			return cv, nil
+		}
+		v, err := semver.NewVersion(cv.Version)
+		if err != nil {
+			continue
+		}
+		if constraint.Check(v) {
+			return cv, nil
+		}
+	}
+	if versionRange != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrChartNotFound, name, versionRange)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrChartNotFound, name)
+}
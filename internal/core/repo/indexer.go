@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"composepack/internal/core/chart"
+)
+
+// archiveSuffix is the extension of packaged charts picked up by IndexDirectory.
+const archiveSuffix = ".cpack.tgz"
+
+// IndexResult summarises an IndexDirectory run.
+type IndexResult struct {
+	Index   *IndexFile
+	Added   []*ChartVersion // archives loaded and hashed in this run
+	Skipped int             // archives already listed in the merged index
+}
+
+// IndexDirectory builds an index for every *.cpack.tgz below dir. URLs are made relative to
+// dir and prefixed with baseURL when set. When existing is non-nil its entries are kept and
+// archives it already lists are not re-read or re-hashed.
+func IndexDirectory(ctx context.Context, loader chart.Loader, dir, baseURL string, existing *IndexFile) (*IndexResult, error) {
+	idx := existing
+	if idx == nil {
+		idx = NewIndexFile()
+	}
+	known := map[string]bool{}
+	for _, versions := range idx.Entries {
+		for _, cv := range versions {
+			for _, u := range cv.URLs {
+				known[u] = true
+			}
+		}
+	}
+
+	result := &IndexResult{Index: idx}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), archiveSuffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		chartURL := filepath.ToSlash(rel)
+		if baseURL != "" {
+			chartURL = strings.TrimSuffix(baseURL, "/") + "/" + chartURL
+		}
+		if known[chartURL] {
+			result.Skipped++
+			return nil
+		}
+
+		ch, err := loader.Load(ctx, path)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", rel, err)
+		}
+		digest, err := chart.FileDigest(path)
+		if err != nil {
+			return err
+		}
+		cv := &ChartVersion{
+			Name:        ch.Metadata.Name,
+			Version:     ch.Metadata.Version,
+			AppVersion:  ch.Metadata.AppVersion,
+			Description: ch.Metadata.Description,
+			URLs:        []string{chartURL},
+			Digest:      digest,
+			Created:     time.Now().UTC(),
+		}
+		if idx.Add(cv) {
+			result.Added = append(result.Added, cv)
+		} else {
+			result.Skipped++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("index %s: %w", dir, err)
+	}
+
+	idx.APIVersion = indexAPIVersion
+	idx.Generated = time.Now().UTC()
+	return result, nil
+}
@@ -0,0 +1,13 @@
+package repo
+
+import (
+	"github.com/google/wire"
+
+	"composepack/internal/core/chart"
+)
+
+// ProviderSet exposes repository wiring for DI.
+var ProviderSet = wire.NewSet(
+	NewClient,
+	wire.Bind(new(chart.RepositoryResolver), new(*Client)),
+)
@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"composepack/internal/util/fsutil"
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Entry names a chart repository served over HTTP(S).
+type Entry struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// File is the on-disk list of configured repositories (repositories.yaml).
+type File struct {
+	Repositories []*Entry `json:"repositories"`
+}
+
+// LoadFile reads a repositories file. A missing file yields an empty list.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &File{}, nil
+		}
+		return nil, fmt.Errorf("read repositories file: %w", err)
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse repositories file %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Save writes the repositories file, sorted by name.
+func (f *File) Save(path string) error {
+	sort.Slice(f.Repositories, func(i, j int) bool { return f.Repositories[i].Name < f.Repositories[j].Name })
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("encode repositories file: %w", err)
+	}
+	return fsutil.WriteFileAtomic(context.Background(), path, data, 0o644)
+}
+
+// Get returns the repository with the given name.
+func (f *File) Get(name string) (*Entry, bool) {
+	for _, entry := range f.Repositories {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// Set adds a repository or replaces the one with the same name.
+func (f *File) Set(entry *Entry) {
+	for i, existing := range f.Repositories {
+		if existing.Name == entry.Name {
+			f.Repositories[i] = entry
+			return
+		}
+	}
+	f.Repositories = append(f.Repositories, entry)
+}
+
+// Remove deletes a repository and reports whether it existed.
+func (f *File) Remove(name string) bool {
+	for i, entry := range f.Repositories {
+		if entry.Name == name {
+			f.Repositories = append(f.Repositories[:i], f.Repositories[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateName rejects repository names that cannot prefix a `repo/chart` reference.
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid repository name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
@@ -9,12 +9,14 @@ import (
 	"sort"
 	"strings"
 
+	"composepack/internal/core/hooks"
 	"composepack/internal/util/fsutil"
 )
 
+// Names of the artifacts the Writer materializes in a runtime directory.
 const (
-	composeFileName = "docker-compose.yaml"
-	filesDirName    = "files"
+	ComposeFileName = "docker-compose.yaml"
+	FilesDir        = "files"
 )
 
 // Writer is responsible for materializing runtime directories per release.
@@ -26,6 +28,7 @@ type WriteOptions struct {
 	BaseDir     string
 	ComposeYAML []byte
 	Files       map[string][]byte
+	HookScripts map[string][]byte // written executable under hooks/
 }
 
 // Write commits the rendered artifacts to `.cpack-releases/<release>`.
@@ -50,12 +53,12 @@ func (w *Writer) Write(ctx context.Context, opts WriteOptions) (string, error) {
 		return "", fmt.Errorf("ensure runtime dir: %w", err)
 	}
 
-	composePath := filepath.Join(runtimeDir, composeFileName)
+	composePath := filepath.Join(runtimeDir, ComposeFileName)
 	if err := fsutil.WriteFileAtomic(ctx, composePath, opts.ComposeYAML, 0o644); err != nil {
 		return "", fmt.Errorf("write compose file: %w", err)
 	}
 
-	filesRoot := filepath.Join(runtimeDir, filesDirName)
+	filesRoot := filepath.Join(runtimeDir, FilesDir)
 	if err := os.RemoveAll(filesRoot); err != nil {
 		return "", fmt.Errorf("clean files dir: %w", err)
 	}
@@ -64,7 +67,17 @@ func (w *Writer) Write(ctx context.Context, opts WriteOptions) (string, error) {
 	}
 
 	if len(opts.Files) > 0 {
-		if err := w.writeFiles(ctx, filesRoot, opts.Files); err != nil {
+		if err := w.writeFiles(ctx, filesRoot, opts.Files, 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	hooksRoot := filepath.Join(runtimeDir, hooks.Dir)
+	if err := os.RemoveAll(hooksRoot); err != nil {
+		return "", fmt.Errorf("clean hooks dir: %w", err)
+	}
+	if len(opts.HookScripts) > 0 {
+		if err := w.writeFiles(ctx, hooksRoot, opts.HookScripts, 0o755); err != nil {
 			return "", err
 		}
 	}
@@ -72,7 +85,7 @@ func (w *Writer) Write(ctx context.Context, opts WriteOptions) (string, error) {
 	return runtimeDir, nil
 }
 
-func (w *Writer) writeFiles(ctx context.Context, root string, files map[string][]byte) error {
+func (w *Writer) writeFiles(ctx context.Context, root string, files map[string][]byte, perm uint32) error {
 	keys := make([]string, 0, len(files))
 	for rel := range files {
 		keys = append(keys, rel)
@@ -93,7 +106,7 @@ func (w *Writer) writeFiles(ctx context.Context, root string, files map[string][
 
 		dest := filepath.Join(root, clean)
 		data := files[rel]
-		if err := fsutil.WriteFileAtomic(ctx, dest, data, 0o644); err != nil {
+		if err := fsutil.WriteFileAtomic(ctx, dest, data, perm); err != nil {
 			return fmt.Errorf("write file %s: %w", rel, err)
 		}
 	}
@@ -3,9 +3,13 @@ package templating
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
 
@@ -22,17 +26,54 @@ func NewEngine() *Engine {
 
 // RenderContext contains the data exposed to templates at runtime.
 type RenderContext struct {
-	Values  map[string]any
-	Env     map[string]string
-	Release ReleaseInfo
-	Chart   chart.ChartMetadata
-	Files   FilesAccessor
+	Values       map[string]any
+	Env          map[string]string
+	Release      ReleaseInfo
+	Chart        chart.ChartMetadata
+	Files        FilesAccessor
+	Capabilities Capabilities
+	Secrets      SecretGenerator // backs genSecret; nil disables it
+	State        StateLookup     // backs lookup; nil disables it
+}
+
+// StateLookup backs the lookup function with the state of the release before the render.
+// kind selects what is read (release, values, services, service, file) and name narrows
+// it; fresh installs yield empty results.
+type StateLookup interface {
+	Lookup(kind, name string) (any, error)
+}
+
+// SecretGenerator returns the secret stored under name, generating one of length
+// characters the first time, so values stay stable across renders.
+type SecretGenerator interface {
+	Secret(name string, length int) (string, error)
 }
 
 // ReleaseInfo mirrors the fields surfaced via `.Release` in templates.
 type ReleaseInfo struct {
-	Name    string
-	Service string
+	Name                 string
+	Service              string
+	Revision             int       // revision being rendered (1 for a new release)
+	IsInstall            bool      // no earlier revision exists
+	IsUpgrade            bool      // an earlier revision exists
+	PreviousChartVersion string    // chart version of the previous revision; empty on install
+	RuntimeDir           string    // release directory the render is written to
+	Time                 time.Time // UTC time of the render, also recorded as createdAt
+}
+
+// Capabilities describes the tooling a release is rendered for, surfaced via
+// `.Capabilities`. Versions are empty when they cannot be detected (e.g. no Docker daemon).
+type Capabilities struct {
+	ComposeVersion     string // docker compose version without a leading v, e.g. 2.29.1
+	Docker             DockerInfo
+	ComposePackVersion string
+}
+
+// DockerInfo describes the Docker engine (`.Capabilities.Docker`).
+type DockerInfo struct {
+	Version string
+	OS      string
+	Arch    string
 }
 
 // FilesAccessor allows templates to read embedded file contents via `.Files`.
@@ -79,6 +120,32 @@ func (e *Engine) RenderComposeFragments(ctx context.Context, ch *chart.Chart, rc
 	return e.renderTemplates(ctx, "compose", ch.ComposeTpls, ch.HelperTpls, rc)
 }
 
+// RenderHooks renders templates/hooks, dropping a trailing .tpl from the names.
+func (e *Engine) RenderHooks(ctx context.Context, ch *chart.Chart, rc RenderContext) (map[string][]byte, error) {
+	rendered, err := e.renderTemplates(ctx, "hooks", ch.HookTpls, ch.HelperTpls, rc)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(rendered))
+	for name, data := range rendered {
+		out[strings.TrimSuffix(name, chart.TemplateFileSuffix)] = data
+	}
+	return out, nil
+}
+
+// RenderNotes renders templates/NOTES.txt, returning nil when the chart has none.
+func (e *Engine) RenderNotes(ctx context.Context, ch *chart.Chart, rc RenderContext) ([]byte, error) {
+	if ch.NotesTpl == "" {
+		return nil, nil
+	}
+	name := path.Base(chart.NotesFile)
+	rendered, err := e.renderTemplates(ctx, "notes", map[string]string{name: ch.NotesTpl}, ch.HelperTpls, rc)
+	if err != nil {
+		return nil, err
+	}
+	return rendered[name], nil
+}
+
 // RenderFiles renders chart file assets (scripts/config) into a runtime tree.
 func (e *Engine) RenderFiles(ctx context.Context, ch *chart.Chart, rc RenderContext) (map[string][]byte, error) {
 	rendered, err := e.renderTemplates(ctx, "files", ch.FileTemplates, ch.HelperTpls, rc)
@@ -146,6 +213,8 @@ func (e *Engine) buildTemplateData(rc RenderContext) map[string]any {
 		"Release": rc.Release,
 		"Chart":   rc.Chart,
 		"Files":   rc.Files,
+
+		"Capabilities": rc.Capabilities,
 	}
 	return data
 }
@@ -153,13 +222,30 @@ func (e *Engine) buildTemplateData(rc RenderContext) map[string]any {
 func (e *Engine) buildFuncMap(rc RenderContext, t *template.Template) template.FuncMap {
 	funcMap := sprig.TxtFuncMap()
 
+	// env only sees the variables captured in the render context so renders are reproducible.
 	funcMap["env"] = func(key string) string {
-		if rc.Env != nil {
-			if v, ok := rc.Env[key]; ok {
-				return v
-			}
+		return rc.Env[key]
+	}
+	funcMap["expandenv"] = func(s string) string {
+		return os.Expand(s, func(key string) string { return rc.Env[key] })
+	}
+
+	// genSecret returns a persisted random value instead of a new one per render (randAlphaNum).
+	funcMap["genSecret"] = func(name string, length int) (string, error) {
+		if rc.Secrets == nil {
+			return "", errors.New("genSecret is not available in this render")
+		}
+		return rc.Secrets.Secret(name, length)
+	}
+
+	funcMap["lookup"] = func(kind string, name ...string) (any, error) {
+		if rc.State == nil {
+			return nil, errors.New("lookup is not available in this render")
+		}
+		if len(name) > 1 {
+			return nil, fmt.Errorf("lookup takes at most one name, got %d", len(name))
 		}
-		return os.Getenv(key)
+		return rc.State.Lookup(kind, strings.Join(name, ""))
 	}
 
 	funcMap["include"] = func(name string, data any) (string, error) {
@@ -0,0 +1,129 @@
+package values
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EnvPrefix marks environment variables that map directly onto values:
+	// COMPOSEPACK_VALUE_db__host=x sets `db.host`.
+	EnvPrefix = "COMPOSEPACK_VALUE_"
+	// EnvSeparator separates nested keys in prefixed variable names.
+	EnvSeparator = "__"
+	// EnvAnnotation is the JSON schema keyword binding a value path to an environment variable.
+	EnvAnnotation = "x-env"
+)
+
+// EnvOverlay builds a values overlay from environment variables using the COMPOSEPACK_VALUE_
+// prefix convention and x-env schema annotations. Prefixed values are typed like --set;
+// x-env values are coerced to the schema `type` of the annotated property.
+func EnvOverlay(env map[string]string, schema []byte) (map[string]any, error) {
+	out := map[string]any{}
+
+	bindings, err := schemaNodes(schema, EnvAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(bindings))
+	for path := range bindings {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		node := bindings[path]
+		name, ok := node[EnvAnnotation].(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s at %s must be a variable name", EnvAnnotation, path)
+		}
+		if strings.Contains(path, Wildcard) {
+			return nil, fmt.Errorf("%s at %s cannot be used below additionalProperties or items", EnvAnnotation, path)
+		}
+		raw, ok := env[name]
+		if !ok {
+			continue
+		}
+		val, err := coerceSchemaType(raw, node["type"])
+		if err != nil {
+			return nil, fmt.Errorf("%s (bound to %s): %w", name, path, err)
+		}
+		setAtPath(out, SplitPath(path), val)
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		if strings.HasPrefix(name, EnvPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rest := strings.TrimPrefix(name, EnvPrefix)
+		segments := strings.Split(rest, EnvSeparator)
+		for _, seg := range segments {
+			if seg == "" {
+				return nil, fmt.Errorf("%s: empty key segment (use %s to separate nested keys)", name, EnvSeparator)
+			}
+		}
+		setAtPath(out, segments, ParseScalar(env[name]))
+	}
+
+	return out, nil
+}
+
+// ParseScalar types a raw string the same way --set does.
+func ParseScalar(s string) any {
+	return typedValue(s)
+}
+
+func coerceSchemaType(raw string, schemaType any) (any, error) {
+	typ, _ := schemaType.(string)
+	switch typ {
+	case "string":
+		return raw, nil
+	case "integer":
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer, got %q", raw)
+		}
+		return n, nil
+	case "number":
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", raw)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("expected a boolean, got %q", raw)
+		}
+		return b, nil
+	case "array", "object":
+		var out any
+		if err := json.Unmarshal([]byte(raw), &out); err != nil {
+			return nil, fmt.Errorf("expected JSON %s: %w", typ, err)
+		}
+		return out, nil
+	default:
+		return ParseScalar(raw), nil
+	}
+}
+
+func setAtPath(dst map[string]any, path []string, val any) {
+	node := dst
+	for _, seg := range path[:len(path)-1] {
+		next, ok := node[seg].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			node[seg] = next
+		}
+		node = next
+	}
+	node[path[len(path)-1]] = val
+}
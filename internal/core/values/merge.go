@@ -0,0 +1,311 @@
+package values
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MergeAnnotation is the JSON schema keyword that selects how a list is merged across layers.
+const MergeAnnotation = "x-merge"
+
+// ListMode enumerates list merge strategies.
+type ListMode string
+
+const (
+	// ListReplace lets the overlay list replace the base list (default).
+	ListReplace ListMode = "replace"
+	// ListAppend appends overlay items after base items.
+	ListAppend ListMode = "append"
+	// ListUnique appends overlay items that are not already present.
+	ListUnique ListMode = "unique"
+	// ListByKey merges map items that share the same key field and appends the rest.
+	ListByKey ListMode = "byKey"
+)
+
+// ListStrategy describes how lists at a given path are merged.
+type ListStrategy struct {
+	Mode ListMode
+	Key  string // field used by ListByKey
+}
+
+// String renders the strategy in its x-merge form.
+func (s ListStrategy) String() string {
+	if s.Mode == ListByKey {
+		return fmt.Sprintf("%s:%s", s.Mode, s.Key)
+	}
+	return string(s.Mode)
+}
+
+// ParseListStrategy parses an x-merge annotation (`append`, `unique`, `replace` or `byKey:<field>`).
+func ParseListStrategy(raw string) (ListStrategy, error) {
+	mode, key, _ := strings.Cut(strings.TrimSpace(raw), ":")
+	switch ListMode(mode) {
+	case ListReplace, ListAppend, ListUnique:
+		if key != "" {
+			return ListStrategy{}, fmt.Errorf("strategy %q does not take a key", mode)
+		}
+		return ListStrategy{Mode: ListMode(mode)}, nil
+	case ListByKey:
+		if key == "" {
+			return ListStrategy{}, fmt.Errorf("strategy %q requires a key (byKey:<field>)", mode)
+		}
+		return ListStrategy{Mode: ListByKey, Key: key}, nil
+	}
+	return ListStrategy{}, fmt.Errorf("unknown merge strategy %q (use replace, append, unique or byKey:<field>)", raw)
+}
+
+// MergeStrategies extracts x-merge annotations from a values JSON schema, keyed by dotted path.
+func MergeStrategies(schema []byte) (map[string]ListStrategy, error) {
+	annotations, err := schemaAnnotations(schema, MergeAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]ListStrategy, len(annotations))
+	for path, raw := range annotations {
+		str, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s at %s must be a string", MergeAnnotation, path)
+		}
+		strategy, err := ParseListStrategy(str)
+		if err != nil {
+			return nil, fmt.Errorf("%s at %s: %w", MergeAnnotation, path, err)
+		}
+		out[path] = strategy
+	}
+	return out, nil
+}
+
+// Layer is one named source of values, e.g. chart defaults, a -f file or --set flags.
+type Layer struct {
+	Source string
+	Values map[string]any
+}
+
+// Assignment records a single layer touching a leaf path.
+type Assignment struct {
+	Source  string `json:"source"`
+	Value   any    `json:"value,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// Trace records, per leaf path, every layer that assigned or deleted it in merge order.
+// Lists are treated as leaves; maps are descended into.
+type Trace struct {
+	leaves map[string][]Assignment
+}
+
+// NewTrace returns an empty trace.
+func NewTrace() *Trace {
+	return &Trace{leaves: map[string][]Assignment{}}
+}
+
+// Paths returns every traced leaf path in sorted order.
+func (t *Trace) Paths() []string {
+	if t == nil {
+		return nil
+	}
+	out := make([]string, 0, len(t.leaves))
+	for p := range t.leaves {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// History returns the assignments for a leaf path, oldest first.
+func (t *Trace) History(path string) []Assignment {
+	if t == nil {
+		return nil
+	}
+	return t.leaves[path]
+}
+
+// Winner returns the last assignment for a leaf path.
+func (t *Trace) Winner(path string) (Assignment, bool) {
+	history := t.History(path)
+	if len(history) == 0 {
+		return Assignment{}, false
+	}
+	return history[len(history)-1], true
+}
+
+func (t *Trace) record(path []string, a Assignment) {
+	if t == nil {
+		return
+	}
+	key := JoinPath(path)
+	t.leaves[key] = append(t.leaves[key], a)
+}
+
+// Merger layers values with optional per-path list strategies and provenance tracing.
+type Merger struct {
+	Strategies map[string]ListStrategy
+	Trace      *Trace
+}
+
+// Merge applies layers in order on top of an empty map.
+func (m *Merger) Merge(layers ...Layer) (map[string]any, error) {
+	result := map[string]any{}
+	patterns := m.compilePatterns()
+	for _, layer := range layers {
+		if layer.Values == nil {
+			continue
+		}
+		if err := m.mergeMaps(result, layer.Values, nil, layer.Source, patterns); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+type strategyPattern struct {
+	path     []string
+	strategy ListStrategy
+}
+
+func (m *Merger) compilePatterns() []strategyPattern {
+	out := make([]strategyPattern, 0, len(m.Strategies))
+	for path, strategy := range m.Strategies {
+		out = append(out, strategyPattern{path: SplitPath(path), strategy: strategy})
+	}
+	return out
+}
+
+func (m *Merger) strategyFor(path []string, patterns []strategyPattern) ListStrategy {
+	for _, p := range patterns {
+		if matchPath(p.path, path) {
+			return p.strategy
+		}
+	}
+	return ListStrategy{Mode: ListReplace}
+}
+
+func (m *Merger) mergeMaps(dst, src map[string]any, path []string, source string, patterns []strategyPattern) error {
+	keys := make([]string, 0, len(src))
+	for key := range src {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		srcVal := src[key]
+		here := appendPath(path, key)
+
+		if srcVal == nil {
+			delete(dst, key)
+			m.Trace.record(here, Assignment{Source: source, Deleted: true})
+			continue
+		}
+
+		if srcMap, ok := toStringMap(srcVal); ok {
+			dstMap, ok := toStringMap(dst[key])
+			if !ok {
+				// Merge into a fresh map so nested nulls are dropped rather than copied.
+				dstMap = map[string]any{}
+				dst[key] = dstMap
+			}
+			if err := m.mergeMaps(dstMap, srcMap, here, source, patterns); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if srcList, ok := srcVal.([]any); ok {
+			if dstList, ok := dst[key].([]any); ok {
+				merged, err := m.mergeLists(dstList, srcList, here, m.strategyFor(here, patterns))
+				if err != nil {
+					return err
+				}
+				dst[key] = merged
+				m.Trace.record(here, Assignment{Source: source, Value: deepCopyValue(merged)})
+				continue
+			}
+		}
+
+		dst[key] = deepCopyValue(srcVal)
+		m.Trace.record(here, Assignment{Source: source, Value: deepCopyValue(srcVal)})
+	}
+	return nil
+}
+
+func (m *Merger) mergeLists(dst, src []any, path []string, strategy ListStrategy) ([]any, error) {
+	switch strategy.Mode {
+	case ListAppend:
+		return append(deepCopySlice(dst), deepCopySlice(src)...), nil
+	case ListUnique:
+		out := deepCopySlice(dst)
+		for _, item := range src {
+			if !containsValue(out, item) {
+				out = append(out, deepCopyValue(item))
+			}
+		}
+		return out, nil
+	case ListByKey:
+		out := deepCopySlice(dst)
+		for _, item := range src {
+			itemMap, ok := toStringMap(item)
+			if !ok {
+				return nil, fmt.Errorf("merge %s by key %q: item %v is not a map", JoinPath(path), strategy.Key, item)
+			}
+			id, ok := itemMap[strategy.Key]
+			if !ok {
+				return nil, fmt.Errorf("merge %s by key %q: item is missing the key", JoinPath(path), strategy.Key)
+			}
+			idx := indexByKey(out, strategy.Key, id)
+			if idx < 0 {
+				fresh := map[string]any{}
+				mergeMaps(fresh, itemMap)
+				out = append(out, fresh)
+				continue
+			}
+			existing, _ := toStringMap(out[idx])
+			mergeMaps(existing, itemMap)
+		}
+		return out, nil
+	default:
+		return deepCopySlice(src), nil
+	}
+}
+
+func containsValue(list []any, val any) bool {
+	for _, item := range list {
+		if equalValues(item, val) {
+			return true
+		}
+	}
+	return false
+}
+
+func indexByKey(list []any, key string, id any) int {
+	for i, item := range list {
+		if m, ok := toStringMap(item); ok && equalValues(m[key], id) {
+			return i
+		}
+	}
+	return -1
+}
+
+// equalValues compares values treating numbers of different Go types (YAML float64,
+// --set int64) as equal when they hold the same number.
+func equalValues(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return fa == fb
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(val any) (float64, bool) {
+	switch typed := val.(type) {
+	case int:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	case float64:
+		return typed, true
+	}
+	return 0, false
+}
@@ -0,0 +1,178 @@
+package values_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"composepack/internal/core/values"
+)
+
+func TestMergeDeletesChartDefaults(t *testing.T) {
+	defaults := map[string]any{
+		"image":   map[string]any{"repository": "nginx", "tag": "1.27"},
+		"sidecar": map[string]any{"enabled": true, "image": "busybox"},
+		"port":    80,
+	}
+	overlay := map[string]any{
+		"image":   map[string]any{"tag": nil, "pullPolicy": "Always"},
+		"sidecar": nil,
+		"missing": nil,
+	}
+
+	got, err := (&values.Merger{}).Merge(values.Layer{Source: "chart", Values: defaults}, values.Layer{Source: "override", Values: overlay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"image": map[string]any{"repository": "nginx", "pullPolicy": "Always"},
+		"port":  80,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Merge() = %#v, want %#v", got, want)
+	}
+	if _, ok := defaults["image"].(map[string]any)["tag"]; !ok {
+		t.Fatal("Merge() modified the chart defaults")
+	}
+}
+
+func TestMergeDropsNestedNullsOfNewMaps(t *testing.T) {
+	got, err := (&values.Merger{}).Merge(values.Layer{Source: "set", Values: map[string]any{
+		"db": map[string]any{"host": "db", "password": nil},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"db": map[string]any{"host": "db"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Merge() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMergeListStrategies(t *testing.T) {
+	base := map[string]any{"list": []any{"a", float64(1), map[string]any{"name": "x"}}}
+	tests := []struct {
+		strategy string
+		overlay  []any
+		want     []any
+	}{
+		{"replace", []any{"b"}, []any{"b"}},
+		{"append", []any{"a", "b"}, []any{"a", float64(1), map[string]any{"name": "x"}, "a", "b"}},
+		// int64 from --set matches the float64 decoded from YAML.
+		{"unique", []any{"a", int64(1), map[string]any{"name": "x"}, "b"}, []any{"a", float64(1), map[string]any{"name": "x"}, "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			strategy, err := values.ParseListStrategy(tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			merger := &values.Merger{Strategies: map[string]values.ListStrategy{"list": strategy}}
+			got, err := merger.Merge(values.Layer{Source: "base", Values: base}, values.Layer{Source: "overlay", Values: map[string]any{"list": tt.overlay}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got["list"], tt.want) {
+				t.Fatalf("list = %#v, want %#v", got["list"], tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeByKey(t *testing.T) {
+	schema := []byte(`{
+		"properties": {
+			"services": {
+				"additionalProperties": {
+					"properties": {
+						"env": {"type": "array", "x-merge": "byKey:name"}
+					}
+				}
+			}
+		}
+	}`)
+	strategies, err := values.MergeStrategies(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strategies["services.*.env"].String(); got != "byKey:name" {
+		t.Fatalf("strategy for services.*.env = %q, want byKey:name", got)
+	}
+
+	base := map[string]any{"services": map[string]any{"web": map[string]any{"env": []any{
+		map[string]any{"name": "LOG", "value": "info"},
+		map[string]any{"name": "PORT", "value": "80"},
+	}}}}
+	overlay := map[string]any{"services": map[string]any{"web": map[string]any{"env": []any{
+		map[string]any{"name": "PORT", "value": "8080"},
+		map[string]any{"name": "DEBUG", "value": "1"},
+	}}}}
+	got, err := (&values.Merger{Strategies: strategies}).Merge(values.Layer{Source: "base", Values: base}, values.Layer{Source: "overlay", Values: overlay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{
+		map[string]any{"name": "LOG", "value": "info"},
+		map[string]any{"name": "PORT", "value": "8080"},
+		map[string]any{"name": "DEBUG", "value": "1"},
+	}
+	env := got["services"].(map[string]any)["web"].(map[string]any)["env"]
+	if !reflect.DeepEqual(env, want) {
+		t.Fatalf("env = %#v, want %#v", env, want)
+	}
+
+	bad := map[string]any{"services": map[string]any{"web": map[string]any{"env": []any{map[string]any{"value": "x"}}}}}
+	_, err = (&values.Merger{Strategies: strategies}).Merge(values.Layer{Source: "base", Values: base}, values.Layer{Source: "bad", Values: bad})
+	if err == nil || !strings.Contains(err.Error(), "missing the key") {
+		t.Fatalf("Merge() error = %v, want a missing key error", err)
+	}
+}
+
+func TestParseListStrategyErrors(t *testing.T) {
+	for _, raw := range []string{"byKey", "append:name", "merge"} {
+		if _, err := values.ParseListStrategy(raw); err == nil {
+			t.Errorf("ParseListStrategy(%q) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestMergeTrace(t *testing.T) {
+	trace := values.NewTrace()
+	merger := &values.Merger{Trace: trace}
+	_, err := merger.Merge(
+		values.Layer{Source: "chart", Values: map[string]any{"replicas": 1, "db": map[string]any{"host": "db", "port": 5432}}},
+		values.Layer{Source: "values.yaml", Values: map[string]any{"replicas": 2, "db": nil}},
+		values.Layer{Source: "set", Values: map[string]any{"replicas": int64(3), "db": map[string]any{"host": "pg"}}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantPaths := []string{"db", "db.host", "db.port", "replicas"}
+	if got := trace.Paths(); !reflect.DeepEqual(got, wantPaths) {
+		t.Fatalf("Paths() = %v, want %v", got, wantPaths)
+	}
+
+	winner, ok := trace.Winner("replicas")
+	if !ok || winner.Source != "set" || winner.Value != int64(3) {
+		t.Fatalf("Winner(replicas) = %+v, %v; want 3 from set", winner, ok)
+	}
+	wantHistory := []values.Assignment{
+		{Source: "chart", Value: 1},
+		{Source: "values.yaml", Value: 2},
+		{Source: "set", Value: int64(3)},
+	}
+	if got := trace.History("replicas"); !reflect.DeepEqual(got, wantHistory) {
+		t.Fatalf("History(replicas) = %+v, want %+v", got, wantHistory)
+	}
+
+	if winner, _ := trace.Winner("db"); !winner.Deleted || winner.Source != "values.yaml" {
+		t.Fatalf("Winner(db) = %+v, want a deletion by values.yaml", winner)
+	}
+	if winner, _ := trace.Winner("db.host"); winner.Source != "set" || winner.Value != "pg" {
+		t.Fatalf("Winner(db.host) = %+v, want pg from set", winner)
+	}
+	if _, ok := trace.Winner("unknown"); ok {
+		t.Fatal("Winner(unknown) found an assignment")
+	}
+}
@@ -0,0 +1,114 @@
+package values
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Wildcard matches any map key or list index in a value path.
+const Wildcard = "*"
+
+// schemaAnnotations walks a values JSON schema and returns the value of a custom keyword
+// (such as x-secret or x-merge) keyed by the dotted value path it applies to.
+// Object properties contribute their name, `additionalProperties` and array `items` contribute "*".
+func schemaAnnotations(schema []byte, keyword string) (map[string]any, error) {
+	nodes, err := schemaNodes(schema, keyword)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]any, len(nodes))
+	for path, node := range nodes {
+		out[path] = node[keyword]
+	}
+	return out, nil
+}
+
+// schemaNodes returns the schema nodes carrying keyword, keyed by dotted value path.
+func schemaNodes(schema []byte, keyword string) (map[string]map[string]any, error) {
+	out := map[string]map[string]any{}
+	if len(schema) == 0 {
+		return out, nil
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, fmt.Errorf("parse values schema: %w", err)
+	}
+	collectNodes(doc, keyword, nil, out)
+	return out, nil
+}
+
+func collectNodes(node map[string]any, keyword string, path []string, out map[string]map[string]any) {
+	if _, ok := node[keyword]; ok && len(path) > 0 {
+		out[JoinPath(path)] = node
+	}
+
+	if props, ok := node["properties"].(map[string]any); ok {
+		for name, child := range props {
+			if childMap, ok := child.(map[string]any); ok {
+				collectNodes(childMap, keyword, appendPath(path, name), out)
+			}
+		}
+	}
+	if extra, ok := node["additionalProperties"].(map[string]any); ok {
+		collectNodes(extra, keyword, appendPath(path, Wildcard), out)
+	}
+	if items, ok := node["items"].(map[string]any); ok {
+		collectNodes(items, keyword, appendPath(path, Wildcard), out)
+	}
+}
+
+// matchPath reports whether a concrete path matches a pattern that may contain "*" segments.
+func matchPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != Wildcard && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// JoinPath renders path segments as a dotted path, escaping literal dots.
+func JoinPath(path []string) string {
+	escaped := make([]string, len(path))
+	for i, seg := range path {
+		escaped[i] = strings.ReplaceAll(seg, ".", `\.`)
+	}
+	return strings.Join(escaped, ".")
+}
+
+// SplitPath is the inverse of JoinPath.
+func SplitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	var (
+		out     []string
+		current strings.Builder
+	)
+	for i := 0; i < len(path); i++ {
+		ch := path[i]
+		if ch == '\\' && i+1 < len(path) && path[i+1] == '.' {
+			current.WriteByte('.')
+			i++
+			continue
+		}
+		if ch == '.' {
+			out = append(out, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(ch)
+	}
+	return append(out, current.String())
+}
+
+func appendPath(path []string, seg string) []string {
+	out := make([]string, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, seg)
+}
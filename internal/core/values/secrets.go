@@ -0,0 +1,139 @@
+package values
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SecretAnnotation is the JSON schema keyword that marks a value as confidential.
+// `"x-secret": true` keeps the value encrypted at rest; `"x-secret": "redact"` drops it entirely.
+const SecretAnnotation = "x-secret"
+
+// SecretMode describes how a secret-marked value is persisted.
+type SecretMode string
+
+const (
+	// SecretEncrypt stores the value encrypted with the release key.
+	SecretEncrypt SecretMode = "encrypt"
+	// SecretRedact replaces the value with a placeholder and never stores it.
+	SecretRedact SecretMode = "redact"
+)
+
+// SecretPaths walks a values JSON schema and returns the dotted paths annotated with x-secret.
+// Object properties contribute their name, `additionalProperties` and array `items` contribute "*".
+func SecretPaths(schema []byte) (map[string]SecretMode, error) {
+	annotations, err := schemaAnnotations(schema, SecretAnnotation)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]SecretMode{}
+	for path, raw := range annotations {
+		mode, err := parseSecretMode(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s at %s: %w", SecretAnnotation, path, err)
+		}
+		if mode != "" {
+			out[path] = mode
+		}
+	}
+	return out, nil
+}
+
+func parseSecretMode(raw any) (SecretMode, error) {
+	switch typed := raw.(type) {
+	case bool:
+		if typed {
+			return SecretEncrypt, nil
+		}
+		return "", nil
+	case string:
+		switch SecretMode(strings.ToLower(typed)) {
+		case SecretEncrypt:
+			return SecretEncrypt, nil
+		case SecretRedact:
+			return SecretRedact, nil
+		}
+	}
+	return "", fmt.Errorf("expected true, false, %q or %q", SecretEncrypt, SecretRedact)
+}
+
+// TransformPaths returns a copy of vals where every leaf matching one of the dotted paths is
+// replaced by fn. Paths may use "*" to match any map key or list index.
+func TransformPaths(vals map[string]any, paths map[string]SecretMode, fn func(path string, mode SecretMode, val any) (any, error)) (map[string]any, error) {
+	out := deepCopyMap(vals)
+	if len(paths) == 0 || out == nil {
+		return out, nil
+	}
+
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+
+	for _, p := range keys {
+		mode := paths[p]
+		if err := transformAt(out, SplitPath(p), nil, func(concrete []string, val any) (any, error) {
+			return fn(JoinPath(concrete), mode, val)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func transformAt(node any, pattern []string, walked []string, fn func(path []string, val any) (any, error)) error {
+	if len(pattern) == 0 {
+		return nil
+	}
+	head, rest := pattern[0], pattern[1:]
+
+	switch typed := node.(type) {
+	case map[string]any:
+		for key, child := range typed {
+			if head != Wildcard && head != key {
+				continue
+			}
+			here := appendPath(walked, key)
+			if len(rest) == 0 {
+				if child == nil {
+					continue
+				}
+				replaced, err := fn(here, child)
+				if err != nil {
+					return err
+				}
+				typed[key] = replaced
+				continue
+			}
+			if err := transformAt(child, rest, here, fn); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, child := range typed {
+			idx := fmt.Sprintf("%d", i)
+			if head != Wildcard && head != idx {
+				continue
+			}
+			here := appendPath(walked, idx)
+			if len(rest) == 0 {
+				if child == nil {
+					continue
+				}
+				replaced, err := fn(here, child)
+				if err != nil {
+					return err
+				}
+				typed[i] = replaced
+				continue
+			}
+			if err := transformAt(child, rest, here, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
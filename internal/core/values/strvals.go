@@ -0,0 +1,414 @@
+package values
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxIndex bounds list indexes in --set expressions so a typo cannot allocate huge slices.
+const maxIndex = 65536
+
+// ParseSet parses Helm-style `--set` expressions (`a.b=1,c[0]=x,d={x,y}`) into dst.
+// Scalars are typed: integers, booleans and `null` are converted, everything else stays a string.
+func ParseSet(expr string, dst map[string]any) error {
+	return parseAssignments(expr, dst, typedValue)
+}
+
+// ParseSetString parses `--set-string` expressions, keeping every scalar as a string.
+func ParseSetString(expr string, dst map[string]any) error {
+	return parseAssignments(expr, dst, func(s string) any { return s })
+}
+
+// ParseSetJSON parses `--set-json` expressions where every value is a JSON document.
+func ParseSetJSON(expr string, dst map[string]any) error {
+	p := &setParser{input: []rune(expr)}
+	for !p.done() {
+		path, err := p.key()
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(strings.NewReader(string(p.input[p.pos:])))
+		dec.UseNumber()
+		var val any
+		if err := dec.Decode(&val); err != nil {
+			return fmt.Errorf("parse JSON value for %s: %w", describePath(path), err)
+		}
+		p.pos += len([]rune(string(p.input[p.pos:])[:dec.InputOffset()]))
+		if err := assignPath(dst, path, normalizeJSON(val)); err != nil {
+			return err
+		}
+		p.skipSpaces()
+		if err := p.separator(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseSetFile parses `--set-file` expressions where every value is a path whose contents become the value.
+func ParseSetFile(expr string, dst map[string]any) error {
+	p := &setParser{input: []rune(expr)}
+	for !p.done() {
+		path, err := p.key()
+		if err != nil {
+			return err
+		}
+		filePath := p.scalar()
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("read file for %s: %w", describePath(path), err)
+		}
+		if err := assignPath(dst, path, string(data)); err != nil {
+			return err
+		}
+		if err := p.separator(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseSetLiteral parses a single `--set-literal` expression. The value is taken verbatim,
+// without comma splitting, escape handling or type conversion.
+func ParseSetLiteral(expr string, dst map[string]any) error {
+	p := &setParser{input: []rune(expr)}
+	path, err := p.key()
+	if err != nil {
+		return err
+	}
+	return assignPath(dst, path, string(p.input[p.pos:]))
+}
+
+func parseAssignments(expr string, dst map[string]any, convert func(string) any) error {
+	p := &setParser{input: []rune(expr)}
+	for !p.done() {
+		path, err := p.key()
+		if err != nil {
+			return err
+		}
+
+		var val any
+		if p.peek() == '{' {
+			list, err := p.list(convert)
+			if err != nil {
+				return fmt.Errorf("parse list for %s: %w", describePath(path), err)
+			}
+			val = list
+		} else {
+			val = convert(p.scalar())
+		}
+
+		if err := assignPath(dst, path, val); err != nil {
+			return err
+		}
+		if err := p.separator(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathSegment is either a map key or a list index.
+type pathSegment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+type setParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *setParser) done() bool { return p.pos >= len(p.input) }
+
+func (p *setParser) peek() rune {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *setParser) skipSpaces() {
+	for !p.done() && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// key reads `a.b[0].c=` and leaves the cursor after '='. Every key segment must be
+// non-empty, and a key after a list index needs a dot (`a[0].b`).
+func (p *setParser) key() ([]pathSegment, error) {
+	var (
+		path       []pathSegment
+		current    strings.Builder
+		afterDot   bool // a key segment must follow
+		afterIndex bool // only '.', '[' or '=' may follow
+	)
+	emptySegment := func() error {
+		return fmt.Errorf("empty key segment in %q", string(p.input))
+	}
+	flush := func() {
+		path = append(path, pathSegment{key: current.String()})
+		current.Reset()
+	}
+
+	for !p.done() {
+		ch := p.input[p.pos]
+		p.pos++
+		switch ch {
+		case '.':
+			switch {
+			case afterIndex:
+				afterIndex = false
+			case current.Len() == 0:
+				return nil, emptySegment()
+			default:
+				flush()
+			}
+			afterDot = true
+		case '[':
+			if afterDot {
+				return nil, emptySegment()
+			}
+			if current.Len() > 0 {
+				flush()
+			}
+			if len(path) == 0 {
+				return nil, fmt.Errorf("list index without a key in %q", string(p.input))
+			}
+			idx, err := p.index()
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, pathSegment{index: idx, isIdx: true})
+			afterIndex = true
+		case '=':
+			if afterDot {
+				return nil, emptySegment()
+			}
+			if current.Len() > 0 {
+				flush()
+			}
+			if len(path) == 0 {
+				return nil, fmt.Errorf("missing key in %q", string(p.input))
+			}
+			return path, nil
+		case ',':
+			return nil, fmt.Errorf("key %q has no value", current.String())
+		default:
+			if afterIndex {
+				return nil, emptySegment()
+			}
+			if ch == '\\' {
+				if p.done() {
+					return nil, fmt.Errorf("unterminated escape in %q", string(p.input))
+				}
+				ch = p.input[p.pos]
+				p.pos++
+			}
+			current.WriteRune(ch)
+			afterDot = false
+		}
+	}
+
+	if current.Len() > 0 || len(path) > 0 {
+		return nil, fmt.Errorf("key %q has no value (expected key=value)", string(p.input))
+	}
+	return nil, fmt.Errorf("missing key in %q", string(p.input))
+}
+
+func (p *setParser) index() (int, error) {
+	start := p.pos
+	for !p.done() && p.input[p.pos] != ']' {
+		p.pos++
+	}
+	if p.done() {
+		return 0, fmt.Errorf("unterminated list index in %q", string(p.input))
+	}
+	raw := string(p.input[start:p.pos])
+	p.pos++ // consume ']'
+
+	idx, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("invalid list index %q", raw)
+	}
+	if idx < 0 {
+		return 0, fmt.Errorf("negative list index %d", idx)
+	}
+	if idx > maxIndex {
+		return 0, fmt.Errorf("list index %d exceeds maximum of %d", idx, maxIndex)
+	}
+	return idx, nil
+}
+
+// scalar reads until an unescaped ',' and honours backslash escapes.
+func (p *setParser) scalar() string {
+	var sb strings.Builder
+	for !p.done() {
+		ch := p.input[p.pos]
+		if ch == ',' {
+			break
+		}
+		p.pos++
+		if ch == '\\' && !p.done() {
+			sb.WriteRune(p.input[p.pos])
+			p.pos++
+			continue
+		}
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
+
+// list reads `{a,b,c}` into a slice.
+func (p *setParser) list(convert func(string) any) ([]any, error) {
+	p.pos++ // consume '{'
+	out := []any{}
+	var (
+		sb      strings.Builder
+		pending bool
+	)
+	for !p.done() {
+		ch := p.input[p.pos]
+		p.pos++
+		switch ch {
+		case '\\':
+			if !p.done() {
+				sb.WriteRune(p.input[p.pos])
+				p.pos++
+			}
+			pending = true
+		case ',':
+			out = append(out, convert(sb.String()))
+			sb.Reset()
+			pending = false
+		case '}':
+			if pending || sb.Len() > 0 || len(out) > 0 {
+				out = append(out, convert(sb.String()))
+			}
+			return out, nil
+		default:
+			sb.WriteRune(ch)
+			pending = true
+		}
+	}
+	return nil, errors.New("list is missing closing '}'")
+}
+
+// separator consumes the ',' between assignments.
+func (p *setParser) separator() error {
+	if p.done() {
+		return nil
+	}
+	if p.input[p.pos] != ',' {
+		return fmt.Errorf("unexpected %q after value in %q", p.input[p.pos], string(p.input))
+	}
+	p.pos++
+	if p.done() {
+		return fmt.Errorf("trailing comma in %q", string(p.input))
+	}
+	return nil
+}
+
+func assignPath(dst map[string]any, path []pathSegment, val any) error {
+	if len(path) == 0 || path[0].isIdx {
+		return errors.New("assignment path must start with a key")
+	}
+	_, err := assignNode(dst, path, val)
+	return err
+}
+
+func assignNode(node any, path []pathSegment, val any) (any, error) {
+	if len(path) == 0 {
+		return val, nil
+	}
+	seg, rest := path[0], path[1:]
+
+	if seg.isIdx {
+		list, _ := node.([]any)
+		for len(list) <= seg.index {
+			list = append(list, nil)
+		}
+		child, err := assignNode(list[seg.index], rest, val)
+		if err != nil {
+			return nil, err
+		}
+		list[seg.index] = child
+		return list, nil
+	}
+
+	m, ok := node.(map[string]any)
+	if !ok {
+		m = map[string]any{}
+	}
+	child, err := assignNode(m[seg.key], rest, val)
+	if err != nil {
+		return nil, err
+	}
+	m[seg.key] = child
+	return m, nil
+}
+
+func describePath(path []pathSegment) string {
+	var sb strings.Builder
+	for i, seg := range path {
+		if seg.isIdx {
+			fmt.Fprintf(&sb, "[%d]", seg.index)
+			continue
+		}
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(seg.key)
+	}
+	return sb.String()
+}
+
+// typedValue mirrors Helm's strvals typing: booleans, null and integers without leading zeros.
+func typedValue(s string) any {
+	switch strings.ToLower(s) {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if s == "0" {
+		return int64(0)
+	}
+	if len(s) > 0 && s[0] != '0' {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	}
+	return s
+}
+
+// normalizeJSON converts json.Number values into int64/float64 so they behave like YAML-decoded numbers.
+func normalizeJSON(val any) any {
+	switch typed := val.(type) {
+	case json.Number:
+		if n, err := typed.Int64(); err == nil {
+			return n
+		}
+		f, _ := typed.Float64()
+		return f
+	case map[string]any:
+		for k, v := range typed {
+			typed[k] = normalizeJSON(v)
+		}
+		return typed
+	case []any:
+		for i, v := range typed {
+			typed[i] = normalizeJSON(v)
+		}
+		return typed
+	default:
+		return val
+	}
+}
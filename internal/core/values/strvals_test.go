@@ -0,0 +1,145 @@
+package values_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"composepack/internal/core/values"
+)
+
+func TestParseSet(t *testing.T) {
+	tests := []struct {
+		expr string
+		want map[string]any
+	}{
+		{"a=1", map[string]any{"a": int64(1)}},
+		{"a=0,b=007,c=1.5,d=-3", map[string]any{"a": int64(0), "b": "007", "c": "1.5", "d": int64(-3)}},
+		{"a=true,b=FALSE,c=yes", map[string]any{"a": true, "b": false, "c": "yes"}},
+		{"a=null", map[string]any{"a": nil}},
+		{"a=", map[string]any{"a": ""}},
+		{"a.b.c=x", map[string]any{"a": map[string]any{"b": map[string]any{"c": "x"}}}},
+		{"a.b=1,a.c=2", map[string]any{"a": map[string]any{"b": int64(1), "c": int64(2)}}},
+		{`a\.b=1`, map[string]any{"a.b": int64(1)}},
+		{`a=x\,y`, map[string]any{"a": "x,y"}},
+		{"a={x,2,true}", map[string]any{"a": []any{"x", int64(2), true}}},
+		{"a={}", map[string]any{"a": []any{}}},
+		{`a={x\,y,z}`, map[string]any{"a": []any{"x,y", "z"}}},
+		{"a[1]=x", map[string]any{"a": []any{nil, "x"}}},
+		{"a[0].b=1,a[2].c=2", map[string]any{"a": []any{map[string]any{"b": int64(1)}, nil, map[string]any{"c": int64(2)}}}},
+		{"a[0][1]=x", map[string]any{"a": []any{[]any{nil, "x"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got := map[string]any{}
+			if err := values.ParseSet(tt.expr, got); err != nil {
+				t.Fatalf("ParseSet(%q) error = %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseSet(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSetMergesIntoExisting(t *testing.T) {
+	dst := map[string]any{"a": map[string]any{"keep": "x"}, "list": []any{"first"}}
+	if err := values.ParseSet("a.b=1,list[2]=third", dst); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"a":    map[string]any{"keep": "x", "b": int64(1)},
+		"list": []any{"first", nil, "third"},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Fatalf("ParseSet() = %#v, want %#v", dst, want)
+	}
+}
+
+func TestParseSetErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{".a=1", "empty key segment"},
+		{"a.=1", "empty key segment"},
+		{"a..b=1", "empty key segment"},
+		{"a.[0]=1", "empty key segment"},
+		{"a[0]b=x", "empty key segment"},
+		{"=1", "missing key"},
+		{"[0]=1", "list index without a key"},
+		{"a", "has no value"},
+		{"a,b=1", "has no value"},
+		{"a=1,", "trailing comma"},
+		{"a[x]=1", "invalid list index"},
+		{"a[-1]=1", "negative list index"},
+		{"a[65537]=1", "exceeds maximum"},
+		{"a[0=1", "unterminated list index"},
+		{"a={x,y", "missing closing '}'"},
+		{"a={x}y", "unexpected"},
+		{`a\`, "unterminated escape"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			err := values.ParseSet(tt.expr, map[string]any{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ParseSet(%q) error = %v, want %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSetVariants(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(file, []byte("line1\nline2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		parse func(string, map[string]any) error
+		expr  string
+		want  map[string]any
+	}{
+		{"string", values.ParseSetString, "a=1,b=true,c=null,d={1,x}", map[string]any{"a": "1", "b": "true", "c": "null", "d": []any{"1", "x"}}},
+		{"json", values.ParseSetJSON, `a={"b":[1,2.5,"x"]},c=null`, map[string]any{"a": map[string]any{"b": []any{int64(1), 2.5, "x"}}, "c": nil}},
+		{"json nested key", values.ParseSetJSON, `a.b[1]="x"`, map[string]any{"a": map[string]any{"b": []any{nil, "x"}}}},
+		{"file", values.ParseSetFile, "tls.cert=" + file, map[string]any{"tls": map[string]any{"cert": "line1\nline2\n"}}},
+		{"literal", values.ParseSetLiteral, `a.b=x,y=\z{1}`, map[string]any{"a": map[string]any{"b": `x,y=\z{1}`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]any{}
+			if err := tt.parse(tt.expr, got); err != nil {
+				t.Fatalf("parse(%q) error = %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parse(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSetVariantErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string, map[string]any) error
+		expr  string
+		want  string
+	}{
+		{"json", values.ParseSetJSON, "a={bad", "parse JSON value for a"},
+		{"json key", values.ParseSetJSON, `a.=1`, "empty key segment"},
+		{"file", values.ParseSetFile, "a=" + filepath.Join(os.TempDir(), "composepack-missing-file"), "read file for a"},
+		{"literal key", values.ParseSetLiteral, "a[0]b=x", "empty key segment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.parse(tt.expr, map[string]any{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("parse(%q) error = %v, want %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
@@ -9,45 +9,21 @@ import (
 )
 
 // Merge merges layered values using ComposePack semantics: maps merge recursively,
-// later scalars/arrays override earlier ones.
+// later scalars/arrays override earlier ones, and a null overlay value deletes the key.
+// Use Merger for per-path list strategies and provenance tracing.
 func Merge(base map[string]any, overlays ...map[string]any) (map[string]any, error) {
-	result := deepCopyMap(base)
-	if result == nil {
-		result = map[string]any{}
-	}
-
+	layers := make([]Layer, 0, len(overlays)+1)
+	layers = append(layers, Layer{Values: base})
 	for _, overlay := range overlays {
-		if overlay == nil {
-			continue
-		}
-		mergeMaps(result, overlay)
+		layers = append(layers, Layer{Values: overlay})
 	}
-
-	return result, nil
+	return (&Merger{}).Merge(layers...)
 }
 
+// mergeMaps merges src into dst with default (replace) list semantics.
 func mergeMaps(dst map[string]any, src map[string]any) {
-	for key, srcVal := range src {
-		if existing, ok := dst[key]; ok {
-			if merged, ok := mergeValues(existing, srcVal); ok {
-				dst[key] = merged
-				continue
-			}
-		}
-		dst[key] = deepCopyValue(srcVal)
-	}
-}
-
-func mergeValues(dstVal, srcVal any) (any, bool) {
-	dstMap, dstIsMap := toStringMap(dstVal)
-	srcMap, srcIsMap := toStringMap(srcVal)
-	if dstIsMap && srcIsMap {
-		mergeMaps(dstMap, srcMap)
-		return dstMap, true
-	}
-
-	// For slices and scalars we simply override with the overlay value.
-	return deepCopyValue(srcVal), true
+	// Without strategies the merger cannot fail.
+	_ = (&Merger{}).mergeMaps(dst, src, nil, "", nil)
 }
 
 func deepCopyMap(src map[string]any) map[string]any {
@@ -0,0 +1,414 @@
+package dependency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/core/repo"
+	"composepack/internal/packager"
+	"composepack/internal/util/fsutil"
+)
+
+const (
+	fileScheme = "file://"
+	repoPrefix = "@" // `@name` refers to a repository added with `composepack repo add`
+)
+
+// Status summarises how a declared dependency relates to charts/ and Chart.lock.
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusMissing  Status = "missing"
+	StatusOutdated Status = "outdated"
+)
+
+// Entry describes one declared dependency for `dependency list`.
+type Entry struct {
+	Name       string
+	Alias      string
+	Version    string // declared range
+	Repository string
+	Locked     string // version pinned in Chart.lock, if any
+	Vendored   string // version found under charts/, if any
+	Status     Status
+}
+
+// Manager resolves Chart.yaml dependencies into charts/ and maintains Chart.lock.
+type Manager struct {
+	Loader chart.Loader
+	Repos  *repo.Client // resolves `@name` and repository URL dependencies
+	Getter chart.Getter // downloads archive URLs
+}
+
+// NewManager builds a dependency manager that inspects charts with loader.
+func NewManager(loader chart.Loader, repos *repo.Client, getter chart.Getter) *Manager {
+	return &Manager{Loader: loader, Repos: repos, Getter: getter}
+}
+
+// Update resolves every dependency range, vendors the matching archives under charts/
+// and rewrites Chart.lock.
+func (m *Manager) Update(ctx context.Context, chartDir string) (*chart.Lock, error) {
+	meta, err := readMetadata(chartDir)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &chart.Lock{
+		Generated: time.Now().UTC(),
+		Digest:    chart.DependenciesDigest(meta.Dependencies),
+	}
+	for _, dep := range meta.Dependencies {
+		if dep.Version != "" {
+			if _, err := semver.NewConstraint(dep.Version); err != nil {
+				return nil, fmt.Errorf("dependency %s: invalid version range %q: %w", dep.Name, dep.Version, err)
+			}
+		}
+		locked, err := m.vendor(ctx, chartDir, dep, func(v *semver.Version) bool {
+			return satisfies(dep.Version, v)
+		}, "")
+		if err != nil {
+			return nil, err
+		}
+		lock.Dependencies = append(lock.Dependencies, locked)
+	}
+	if err := m.prune(ctx, filepath.Join(chartDir, chart.ChartsDir), lock.Dependencies); err != nil {
+		return nil, err
+	}
+
+	if err := chart.WriteLock(chartDir, lock); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Build restores charts/ from the exact versions recorded in Chart.lock.
+func (m *Manager) Build(ctx context.Context, chartDir string) (*chart.Lock, error) {
+	meta, err := readMetadata(chartDir)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := chart.LoadLock(chartDir)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, fmt.Errorf("%s not found (run `composepack dependency update` first)", chart.LockFile)
+	}
+	if lock.Digest != chart.DependenciesDigest(meta.Dependencies) {
+		return nil, fmt.Errorf("%s is out of date with %s (run `composepack dependency update`)", chart.LockFile, chart.MetadataFile)
+	}
+
+	for _, locked := range lock.Dependencies {
+		target := filepath.Join(chartDir, chart.ChartsDir, archiveName(locked.Name, locked.Version))
+		if digest, err := chart.FileDigest(target); err == nil && digest == locked.Digest {
+			continue
+		}
+
+		dep := chart.Dependency{Name: locked.Name, Alias: locked.Alias, Version: locked.Version, Repository: locked.Repository}
+		if _, err := m.vendor(ctx, chartDir, dep, func(v *semver.Version) bool {
+			return v.Original() == locked.Version || v.String() == locked.Version
+		}, locked.Digest); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.prune(ctx, filepath.Join(chartDir, chart.ChartsDir), lock.Dependencies); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// List reports the status of every declared dependency.
+func (m *Manager) List(ctx context.Context, chartDir string) ([]Entry, error) {
+	meta, err := readMetadata(chartDir)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := chart.LoadLock(chartDir)
+	if err != nil {
+		return nil, err
+	}
+	stale := lock == nil || lock.Digest != chart.DependenciesDigest(meta.Dependencies)
+
+	vendored, err := m.vendored(ctx, filepath.Join(chartDir, chart.ChartsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(meta.Dependencies))
+	for _, dep := range meta.Dependencies {
+		entry := Entry{
+			Name:       dep.Name,
+			Alias:      dep.Alias,
+			Version:    dep.Version,
+			Repository: dep.Repository,
+			Status:     StatusMissing,
+		}
+		var locked *chart.LockedDependency
+		if lock != nil {
+			if l, ok := lock.Find(dep.Key()); ok {
+				locked = &l
+				entry.Locked = l.Version
+			}
+		}
+
+		var versions []string
+		for _, v := range vendored {
+			if v.meta.Name == dep.Name {
+				versions = append(versions, v.meta.Version)
+			}
+		}
+		if len(versions) == 0 {
+			entries = append(entries, entry)
+			continue
+		}
+
+		entry.Status = StatusOutdated
+		for _, version := range versions {
+			parsed, err := semver.NewVersion(version)
+			if err != nil || !satisfies(dep.Version, parsed) {
+				continue
+			}
+			if locked != nil && !stale && locked.Version != version {
+				continue
+			}
+			entry.Vendored = version
+			if !stale {
+				entry.Status = StatusOK
+			}
+			break
+		}
+		if entry.Vendored == "" {
+			entry.Vendored = strings.Join(versions, ", ")
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// vendor fetches a dependency, checks the chart it contains and stores it as
+// charts/<name>-<version>.cpack.tgz. When wantDigest is set, the vendored archive must match it.
+func (m *Manager) vendor(ctx context.Context, chartDir string, dep chart.Dependency, accept func(*semver.Version) bool, wantDigest string) (chart.LockedDependency, error) {
+	locked := chart.LockedDependency{Name: dep.Name, Alias: dep.Alias, Repository: dep.Repository}
+
+	source, cleanup, err := m.resolveSource(ctx, chartDir, dep)
+	if err != nil {
+		return locked, err
+	}
+	defer cleanup()
+
+	ch, err := m.Loader.Load(ctx, source)
+	if err != nil {
+		return locked, fmt.Errorf("dependency %s: load %s: %w", dep.Name, dep.Repository, err)
+	}
+	if ch.Metadata.Name != dep.Name {
+		return locked, fmt.Errorf("dependency %s: %s contains chart %s", dep.Name, dep.Repository, ch.Metadata.Name)
+	}
+	version, err := semver.NewVersion(ch.Metadata.Version)
+	if err != nil {
+		return locked, fmt.Errorf("dependency %s: chart version %q is not semver: %w", dep.Name, ch.Metadata.Version, err)
+	}
+	if !accept(version) {
+		return locked, fmt.Errorf("dependency %s: %s provides version %s, which does not satisfy %q", dep.Name, dep.Repository, ch.Metadata.Version, dep.Version)
+	}
+	locked.Version = ch.Metadata.Version
+
+	chartsDir := filepath.Join(chartDir, chart.ChartsDir)
+	if err := fsutil.EnsureDir(chartsDir); err != nil {
+		return locked, fmt.Errorf("create %s: %w", chart.ChartsDir, err)
+	}
+	name := archiveName(dep.Name, locked.Version)
+	target := filepath.Join(chartsDir, name)
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return locked, err
+	}
+	if info.IsDir() {
+		// Reproducible packaging makes directory dependencies checkable against the
+		// locked digest as well, as long as their sources are unchanged.
+		if _, err := packager.PackageChart(ctx, m.Loader, packager.Options{
+			ChartPath:    source,
+			Destination:  chartsDir,
+			OutputName:   name,
+			Force:        true,
+			Reproducible: true,
+		}); err != nil {
+			return locked, fmt.Errorf("dependency %s: %w", dep.Name, err)
+		}
+	} else if err := copyFile(source, target); err != nil {
+		return locked, fmt.Errorf("dependency %s: %w", dep.Name, err)
+	}
+
+	locked.Digest, err = chart.FileDigest(target)
+	if err != nil {
+		return locked, err
+	}
+	if wantDigest != "" && locked.Digest != wantDigest {
+		os.Remove(target)
+		hint := ""
+		if info.IsDir() {
+			hint = "; run `composepack dependency update` if its sources changed"
+		}
+		return locked, fmt.Errorf("dependency %s: digest mismatch for %s (locked %s, got %s)%s", dep.Name, dep.Repository, wantDigest, locked.Digest, hint)
+	}
+	return locked, nil
+}
+
+// resolveSource maps a dependency repository to a local chart directory or archive:
+// file:// paths, archive URLs, `@name` for a repository added with `repo add`, or the
+// base URL of a chart repository serving index.yaml.
+func (m *Manager) resolveSource(ctx context.Context, chartDir string, dep chart.Dependency) (string, func(), error) {
+	repository := dep.Repository
+	switch {
+	case repository == "":
+		return "", nil, fmt.Errorf("dependency %s has no repository (vendor it under %s/ manually)", dep.Name, chart.ChartsDir)
+	case strings.HasPrefix(repository, fileScheme):
+		path := strings.TrimPrefix(repository, fileScheme)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(chartDir, path)
+		}
+		return path, func() {}, nil
+	case chart.IsURL(repository) && chart.IsArchive(repository):
+		return chart.Fetch(ctx, m.Getter, repository)
+	case chart.IsURL(repository), strings.HasPrefix(repository, repoPrefix):
+		if m.Repos == nil {
+			return "", nil, fmt.Errorf("dependency %s: chart repositories are not configured", dep.Name)
+		}
+		var (
+			url, digest string
+			err         error
+		)
+		if chart.IsURL(repository) {
+			url, digest, err = m.Repos.ResolveFromURL(ctx, repository, dep.Name, dep.Version)
+		} else {
+			var ok bool
+			url, digest, ok, err = m.Repos.ResolveChart(ctx, strings.TrimPrefix(repository, repoPrefix)+"/"+dep.Name, dep.Version)
+			if err == nil && !ok {
+				err = fmt.Errorf("repository %s not found (run `composepack repo add`)", strings.TrimPrefix(repository, repoPrefix))
+			}
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("dependency %s: %w", dep.Name, err)
+		}
+		path, cleanup, err := chart.Fetch(ctx, m.Getter, url)
+		if err != nil {
+			return "", nil, fmt.Errorf("dependency %s: %w", dep.Name, err)
+		}
+		if digest != "" {
+			if err := chart.VerifyDigest(path, digest); err != nil {
+				cleanup()
+				return "", nil, fmt.Errorf("dependency %s: %s: %w (from the repository index)", dep.Name, url, err)
+			}
+		}
+		return path, cleanup, nil
+	default:
+		return "", nil, fmt.Errorf("dependency %s: unsupported repository %q (use file://, a URL or @repo)", dep.Name, repository)
+	}
+}
+
+type vendoredChart struct {
+	path string
+	meta chart.ChartMetadata
+}
+
+func (m *Manager) vendored(ctx context.Context, chartsDir string) ([]vendoredChart, error) {
+	entries, err := os.ReadDir(chartsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", chart.ChartsDir, err)
+	}
+
+	var out []vendoredChart
+	for _, entry := range entries {
+		path := filepath.Join(chartsDir, entry.Name())
+		if !entry.IsDir() && !chart.IsArchive(path) {
+			continue
+		}
+		ch, err := m.Loader.Load(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", filepath.Join(chart.ChartsDir, entry.Name()), err)
+		}
+		out = append(out, vendoredChart{path: path, meta: ch.Metadata})
+	}
+	return out, nil
+}
+
+// prune deletes the archives of declared charts that no locked dependency uses anymore,
+// such as versions vendored by an earlier update. It runs once every dependency is
+// resolved, because aliases of one chart may lock different versions.
+func (m *Manager) prune(ctx context.Context, chartsDir string, locked []chart.LockedDependency) error {
+	keep := map[string]bool{}
+	names := map[string]bool{}
+	for _, dep := range locked {
+		keep[archiveName(dep.Name, dep.Version)] = true
+		names[dep.Name] = true
+	}
+	vendored, err := m.vendored(ctx, chartsDir)
+	if err != nil {
+		return err
+	}
+	for _, v := range vendored {
+		if !names[v.meta.Name] || keep[filepath.Base(v.path)] || !chart.IsArchive(v.path) {
+			continue
+		}
+		if err := os.Remove(v.path); err != nil {
+			return fmt.Errorf("remove stale %s: %w", filepath.Base(v.path), err)
+		}
+	}
+	return nil
+}
+
+func readMetadata(chartDir string) (*chart.ChartMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(chartDir, chart.MetadataFile))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", chart.MetadataFile, err)
+	}
+	return chart.ParseMetadata(data)
+}
+
+func satisfies(versionRange string, v *semver.Version) bool {
+	if versionRange == "" {
+		return true
+	}
+	c, err := semver.NewConstraint(versionRange)
+	if err != nil {
+		return false
+	}
+	return c.Check(v)
+}
+
+// archiveName names a vendored archive. The alias is deliberately not part of it: the loader
+// matches vendored charts by name and version range, so aliases locking the same version
+// share one archive and aliases locking different versions get one archive each.
+func archiveName(name, version string) string {
+	return fmt.Sprintf("%s-%s.cpack.tgz", name, version)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("copy %s: %w", src, err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,205 @@
+package dependency_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"composepack/internal/core/chart"
+	"composepack/internal/dependency"
+	"composepack/internal/util/fileloader"
+)
+
+// fixture lays out app/ depending on two versions of pg through file:// paths:
+// pga on ../pg1 (1.0.0) and pgb on ../pg2 (2.0.0).
+func fixture(t *testing.T) (string, *dependency.Manager) {
+	t.Helper()
+	root := t.TempDir()
+	writeChart(t, filepath.Join(root, "pg1"), "name: pg\nversion: 1.0.0\n")
+	writeChart(t, filepath.Join(root, "pg2"), "name: pg\nversion: 2.0.0\n")
+	writeChart(t, filepath.Join(root, "app"), appChart("^1.0.0", "^2.0.0"))
+
+	loader := chart.NewCompositeLoader(chart.NewFileSystemChartLoader(fileloader.NewFileSystemLoader()), nil, nil, nil, nil)
+	return filepath.Join(root, "app"), dependency.NewManager(loader, nil, nil)
+}
+
+func appChart(pga, pgb string) string {
+	return "apiVersion: v2\nname: app\nversion: 0.1.0\ndependencies:\n" +
+		"  - name: pg\n    alias: pga\n    version: \"" + pga + "\"\n    repository: file://../pg1\n" +
+		"  - name: pg\n    alias: pgb\n    version: \"" + pgb + "\"\n    repository: file://../pg2\n"
+}
+
+func writeChart(t *testing.T, dir, metadata string) {
+	t.Helper()
+	files := map[string]string{
+		chart.MetadataFile: metadata,
+		chart.ValuesFile:   "",
+		filepath.Join(filepath.FromSlash(chart.TemplatesCompose), "db.yaml"): "services:\n  db:\n    image: postgres:16\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func vendoredArchives(t *testing.T, chartDir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(chartDir, chart.ChartsDir, "*.cpack.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(matches))
+	for i, match := range matches {
+		names[i] = filepath.Base(match)
+	}
+	return names
+}
+
+func statuses(t *testing.T, m *dependency.Manager, chartDir string) map[string]dependency.Entry {
+	t.Helper()
+	entries, err := m.List(context.Background(), chartDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]dependency.Entry{}
+	for _, entry := range entries {
+		out[entry.Alias] = entry
+	}
+	return out
+}
+
+func TestUpdateBuildList(t *testing.T) {
+	ctx := context.Background()
+	app, m := fixture(t)
+
+	for alias, entry := range statuses(t, m, app) {
+		if entry.Status != dependency.StatusMissing {
+			t.Fatalf("%s before update: status %s, want missing", alias, entry.Status)
+		}
+	}
+
+	lock, err := m.Update(ctx, app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lock.Dependencies) != 2 || lock.Dependencies[0].Version != "1.0.0" || lock.Dependencies[1].Version != "2.0.0" {
+		t.Fatalf("Update() locked %+v, want pga 1.0.0 and pgb 2.0.0", lock.Dependencies)
+	}
+	if want := []string{"pg-1.0.0.cpack.tgz", "pg-2.0.0.cpack.tgz"}; !reflect.DeepEqual(vendoredArchives(t, app), want) {
+		t.Fatalf("charts/ = %v, want %v", vendoredArchives(t, app), want)
+	}
+	written, err := chart.LoadLock(app)
+	if err != nil || written == nil || written.Digest != lock.Digest {
+		t.Fatalf("Chart.lock = %+v, %v; want the returned lock", written, err)
+	}
+	for alias, entry := range statuses(t, m, app) {
+		if entry.Status != dependency.StatusOK || entry.Locked != entry.Vendored {
+			t.Fatalf("%s after update: %+v, want ok with the locked version vendored", alias, entry)
+		}
+	}
+
+	// Build restores the exact archives from the lock.
+	if err := os.RemoveAll(filepath.Join(app, chart.ChartsDir)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Build(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	for _, locked := range lock.Dependencies {
+		digest, err := chart.FileDigest(filepath.Join(app, chart.ChartsDir, locked.Name+"-"+locked.Version+".cpack.tgz"))
+		if err != nil || digest != locked.Digest {
+			t.Fatalf("rebuilt %s %s: digest %s, %v; want %s", locked.Alias, locked.Version, digest, err, locked.Digest)
+		}
+	}
+}
+
+func TestUpdatePrunesReplacedVersions(t *testing.T) {
+	ctx := context.Background()
+	app, m := fixture(t)
+	if _, err := m.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	writeChart(t, filepath.Join(filepath.Dir(app), "pg1"), "name: pg\nversion: 1.1.0\n")
+	if _, err := m.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	// pgb still locks 2.0.0, so only the archive replaced for pga goes.
+	if want := []string{"pg-1.1.0.cpack.tgz", "pg-2.0.0.cpack.tgz"}; !reflect.DeepEqual(vendoredArchives(t, app), want) {
+		t.Fatalf("charts/ = %v, want %v", vendoredArchives(t, app), want)
+	}
+}
+
+func TestListOutdated(t *testing.T) {
+	ctx := context.Background()
+	app, m := fixture(t)
+	if _, err := m.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Narrowing pga's range leaves no vendored version for it and makes the lock stale.
+	writeChart(t, app, appChart("^3.0.0", "^2.0.0"))
+	entries := statuses(t, m, app)
+	if pga := entries["pga"]; pga.Status != dependency.StatusOutdated || pga.Vendored != "1.0.0, 2.0.0" {
+		t.Fatalf("pga = %+v, want outdated listing the vendored versions", pga)
+	}
+	if pgb := entries["pgb"]; pgb.Status != dependency.StatusOutdated || pgb.Vendored != "2.0.0" {
+		t.Fatalf("pgb = %+v, want outdated (stale lock) with 2.0.0 vendored", pgb)
+	}
+
+	if _, err := m.Build(ctx, app); err == nil || !strings.Contains(err.Error(), "out of date") {
+		t.Fatalf("Build() error = %v, want a stale lock error", err)
+	}
+	if _, err := m.Update(ctx, app); err == nil || !strings.Contains(err.Error(), "does not satisfy") {
+		t.Fatalf("Update() error = %v, want an unsatisfiable range error", err)
+	}
+}
+
+func TestListMissingArchive(t *testing.T) {
+	ctx := context.Background()
+	app, m := fixture(t)
+	if _, err := m.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(app, chart.ChartsDir, "pg-2.0.0.cpack.tgz")); err != nil {
+		t.Fatal(err)
+	}
+
+	// pgb locks 2.0.0, which is gone; 1.0.0 does not satisfy ^2.0.0.
+	entries := statuses(t, m, app)
+	if pga := entries["pga"]; pga.Status != dependency.StatusOK {
+		t.Fatalf("pga = %+v, want ok", pga)
+	}
+	if pgb := entries["pgb"]; pgb.Status != dependency.StatusOutdated || pgb.Locked != "2.0.0" {
+		t.Fatalf("pgb = %+v, want outdated with 2.0.0 locked", pgb)
+	}
+}
+
+func TestBuildDetectsChangedSources(t *testing.T) {
+	ctx := context.Background()
+	app, m := fixture(t)
+	if _, err := m.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(app, chart.ChartsDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same version, different content.
+	pg1 := filepath.Join(filepath.Dir(app), "pg1")
+	if err := os.WriteFile(filepath.Join(pg1, chart.ValuesFile), []byte("replicas: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := m.Build(ctx, app)
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("Build() error = %v, want a digest mismatch", err)
+	}
+}
@@ -9,7 +9,11 @@ import (
 
 	"composepack/internal/app"
 	"composepack/internal/core/chart"
+	"composepack/internal/core/chartcache"
+	"composepack/internal/core/oci"
+	"composepack/internal/core/repo"
 	"composepack/internal/infra/config"
+	"composepack/internal/infra/download"
 	"composepack/internal/infra/logging"
 )
 
@@ -28,6 +32,10 @@ func InitializeApplication() (*app.Application, error) {
 	wire.Build(
 		provideConfig,
 		provideLogger,
+		download.ProviderSet,
+		chartcache.ProviderSet,
+		repo.ProviderSet,
+		oci.ProviderSet,
 		chart.ProviderSet,
 		app.NewRuntime,
 		app.NewApplication,
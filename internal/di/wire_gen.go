@@ -9,7 +9,11 @@ package di
 import (
 	"composepack/internal/app"
 	"composepack/internal/core/chart"
+	"composepack/internal/core/chartcache"
+	"composepack/internal/core/oci"
+	"composepack/internal/core/repo"
 	"composepack/internal/infra/config"
+	"composepack/internal/infra/download"
 	"composepack/internal/infra/logging"
 	"composepack/internal/util/fileloader"
 )
@@ -22,8 +26,12 @@ func InitializeApplication() (*app.Application, error) {
 	logger := provideLogger()
 	fileSystemLoader := fileloader.NewFileSystemLoader()
 	fileSystemChartLoader := chart.NewFileSystemChartLoader(fileSystemLoader)
-	compositeLoader := chart.NewCompositeLoader(fileSystemChartLoader)
-	runtime := app.NewRuntime(config, logger, compositeLoader)
+	downloadClient := download.New(config)
+	cache := chartcache.NewCache(config, downloadClient)
+	client := repo.NewClient(config, downloadClient)
+	ociClient := oci.NewClient(downloadClient)
+	compositeLoader := chart.NewCompositeLoader(fileSystemChartLoader, cache, cache, client, ociClient)
+	runtime := app.NewRuntime(config, logger, compositeLoader, downloadClient, cache, client, ociClient)
 	application := app.NewApplication(runtime)
 	return application, nil
 }
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	ms "github.com/go-viper/mapstructure/v2"
 )
@@ -9,15 +11,53 @@ import (
 // Config contains process-wide settings derived from flags/env.
 type Config struct {
 	ReleasesBaseDir string `mapstructure:"releases_base_dir"`
+	RepositoryFile  string `mapstructure:"repository_config"` // repositories.yaml listing named chart repositories
+	RepositoryCache string `mapstructure:"repository_cache"`  // directory holding downloaded index.yaml files
+	ChartCache      string `mapstructure:"chart_cache"`       // content-addressed cache of downloaded chart archives
+	HTTPConfigFile  string `mapstructure:"http_config"`       // hosts.yaml with per-host download credentials and TLS settings
+	NetrcFile       string `mapstructure:"netrc"`             // .netrc consulted for download credentials
+	Keyring         string `mapstructure:"keyring"`           // PEM public keys trusted by --verify
 }
 
 // Default returns baseline configuration derived from the PRD runtime layout.
 func Default() Config {
 	return Config{
 		ReleasesBaseDir: ".cpack-releases",
+		RepositoryFile:  envOr("COMPOSEPACK_REPOSITORY_CONFIG", userPath(os.UserConfigDir, "repositories.yaml")),
+		RepositoryCache: envOr("COMPOSEPACK_REPOSITORY_CACHE", userPath(os.UserCacheDir, "repository")),
+		ChartCache:      envOr("COMPOSEPACK_CHART_CACHE", userPath(os.UserCacheDir, "charts")),
+		HTTPConfigFile:  envOr("COMPOSEPACK_HTTP_CONFIG", userPath(os.UserConfigDir, "hosts.yaml")),
+		NetrcFile:       envOr("NETRC", homePath(".netrc")),
+		Keyring:         envOr("COMPOSEPACK_KEYRING", userPath(os.UserConfigDir, "keyring.pem")),
 	}
 }
 
+// userPath places name under the composepack folder of a per-user base directory,
+// falling back to the working directory when the base is unavailable.
+func userPath(base func() (string, error), name string) string {
+	dir, err := base()
+	if err != nil || dir == "" {
+		dir = "."
+	}
+	return filepath.Join(dir, "composepack", name)
+}
+
+// homePath places name in the user's home directory (empty when it is unknown).
+func homePath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, name)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 // NewWithSubstitute creates a new config with the given substitutions mappings.
 // The substitutions map is a mapping of config keys to values.
 func NewWithSubstitutions(substitutions map[string]string) (Config, error) {
@@ -0,0 +1,470 @@
+// Package download fetches chart archives and repository indexes over HTTP(S) with
+// credentials, custom TLS roots, retries and a size limit.
+package download
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"composepack/internal/infra/config"
+)
+
+// Defaults applied by New.
+const (
+	DefaultTimeout = 5 * time.Minute
+	DefaultRetries = 3
+	DefaultMaxSize = 100 << 20 // 100Mi
+
+	maxBackoff = 10 * time.Second
+	userAgent  = "composepack"
+)
+
+// ErrTooLarge is returned when a response exceeds Options.MaxSize.
+var ErrTooLarge = errors.New("download exceeds size limit")
+
+// ErrOffline is returned for any download attempted while Options.Offline is set.
+var ErrOffline = errors.New("offline mode: network access disabled")
+
+// Options are the command-wide download settings. Credentials and TLS settings given here
+// apply to every request and win over the hosts file and .netrc.
+type Options struct {
+	Username              string
+	Password              string
+	Token                 string // bearer token
+	CAFile                string // PEM bundle trusted in addition to the system roots
+	CertFile              string // client certificate (PEM)
+	KeyFile               string // client key (PEM)
+	InsecureSkipTLSVerify bool
+	Timeout               time.Duration // per attempt, including reading the body
+	Retries               int           // extra attempts after network errors, 429 and 5xx responses
+	MaxSize               int64         // bytes; 0 disables the limit
+	Offline               bool          // fail every download with ErrOffline
+}
+
+// Validators identify a previously downloaded response for conditional requests.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Response describes a completed download.
+type Response struct {
+	NotModified bool // the server answered 304 to the Validators; nothing was downloaded
+	Validators
+}
+
+// Client downloads http(s) URLs. Per-host credentials and TLS settings are read lazily
+// from HostsFile and NetrcFile.
+type Client struct {
+	Options
+	HostsFile string
+	NetrcFile string
+
+	mu      sync.Mutex
+	hosts   *HostsFile
+	netrc   []netrcEntry
+	loaded  bool
+	clients map[string]*http.Client // keyed by host
+}
+
+// New builds a client with the default limits and the configured hosts/netrc files.
+func New(cfg config.Config) *Client {
+	return &Client{
+		Options:   Options{Timeout: DefaultTimeout, Retries: DefaultRetries, MaxSize: DefaultMaxSize},
+		HostsFile: cfg.HTTPConfigFile,
+		NetrcFile: cfg.NetrcFile,
+	}
+}
+
+// Get downloads rawURL into memory.
+func (c *Client) Get(ctx context.Context, rawURL string) ([]byte, error) {
+	var data []byte
+	_, err := c.download(ctx, rawURL, Validators{}, func(body io.Reader) error {
+		var err error
+		data, err = io.ReadAll(body)
+		return err
+	})
+	return data, err
+}
+
+// Fetch downloads rawURL to a temporary file named after pattern (see os.CreateTemp) and
+// returns its path plus a cleanup func.
+func (c *Client) Fetch(ctx context.Context, rawURL, pattern string) (string, func(), error) {
+	path, cleanup, _, err := c.FetchIfModified(ctx, rawURL, pattern, Validators{})
+	return path, cleanup, err
+}
+
+// FetchIfModified is Fetch with a conditional request. When the server reports the content
+// unchanged, no file is created and the returned path is empty.
+func (c *Client) FetchIfModified(ctx context.Context, rawURL, pattern string, validators Validators) (string, func(), *Response, error) {
+	tmp, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("create temp file: %w", err)
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+
+	resp, err := c.download(ctx, rawURL, validators, func(body io.Reader) error {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := tmp.Truncate(0); err != nil {
+			return err
+		}
+		_, err := io.Copy(tmp, body)
+		return err
+	})
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("save download: %w", closeErr)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	if resp.NotModified {
+		cleanup()
+		return "", func() {}, resp, nil
+	}
+	return tmp.Name(), cleanup, resp, nil
+}
+
+// download performs the request with retries, passing the size-limited body to consume.
+func (c *Client) download(ctx context.Context, rawURL string, validators Validators, consume func(io.Reader) error) (*Response, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse URL %q: %w", rawURL, err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("download %s: unsupported scheme %q", target.Redacted(), target.Scheme)
+	}
+	if c.Offline {
+		return nil, fmt.Errorf("download %s: %w", target.Redacted(), ErrOffline)
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	client, err := c.httpClient(target)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", target.Redacted(), err)
+	}
+
+	attempts := c.Retries + 1
+	if attempts < 1 {
+		attempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		resp, retryAfter, err := c.attempt(ctx, client, target, validators, consume)
+		if err == nil {
+			return resp, nil
+		}
+		var retry *retryableError
+		if !errors.As(err, &retry) || attempt >= attempts || ctx.Err() != nil {
+			if attempt > 1 {
+				return nil, fmt.Errorf("download %s: %w (after %d attempts)", target.Redacted(), err, attempt)
+			}
+			return nil, fmt.Errorf("download %s: %w", target.Redacted(), err)
+		}
+
+		wait := retryAfter
+		if wait <= 0 {
+			wait = backoff(attempt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("download %s: %w", target.Redacted(), ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// retryableError marks failures worth another attempt.
+type retryableError struct{ err error }
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+func (c *Client) attempt(ctx context.Context, client *http.Client, target *url.URL, validators Validators, consume func(io.Reader) error) (*Response, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+	c.authorize(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if isTLSError(err) {
+			return nil, 0, err
+		}
+		return nil, 0, &retryableError{err}
+	}
+	defer resp.Body.Close()
+
+	result := &Response{Validators: Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNotModified && (validators.ETag != "" || validators.LastModified != ""):
+		result.NotModified = true
+		return result, 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented):
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), &retryableError{fmt.Errorf("unexpected status %s", resp.Status)}
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, 0, fmt.Errorf("unexpected status %s (configure credentials with --username/--password, --token, %s or .netrc)", resp.Status, hostsFileHint(c.HostsFile))
+	default:
+		return nil, 0, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if c.MaxSize > 0 && resp.ContentLength > c.MaxSize {
+		return nil, 0, fmt.Errorf("%w: %d bytes (limit %d)", ErrTooLarge, resp.ContentLength, c.MaxSize)
+	}
+	body := io.Reader(resp.Body)
+	if c.MaxSize > 0 {
+		body = &limitedReader{r: resp.Body, remaining: c.MaxSize}
+	}
+	if err := consume(body); err != nil {
+		if errors.Is(err, ErrTooLarge) {
+			return nil, 0, fmt.Errorf("%w (limit %d bytes)", ErrTooLarge, c.MaxSize)
+		}
+		return nil, 0, &retryableError{fmt.Errorf("read body: %w", err)}
+	}
+	return result, 0, nil
+}
+
+// authorize applies flag credentials, then the hosts file, then .netrc. Credentials in the
+// URL itself are left to net/http.
+func (c *Client) authorize(req *http.Request) {
+	if req.URL.User != nil {
+		return
+	}
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		return
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+		return
+	}
+	if host := c.hosts.lookup(req.URL); host != nil {
+		if token := host.token(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return
+		}
+		if host.Username != "" {
+			req.SetBasicAuth(host.Username, host.password())
+			return
+		}
+	}
+	if entry := lookupNetrc(c.netrc, req.URL.Hostname()); entry != nil {
+		req.SetBasicAuth(entry.login, entry.password)
+	}
+}
+
+// load reads the hosts file and .netrc once.
+func (c *Client) load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded {
+		return nil
+	}
+	hosts, err := LoadHostsFile(c.HostsFile)
+	if err != nil {
+		return err
+	}
+	entries, err := loadNetrc(c.NetrcFile)
+	if err != nil {
+		return err
+	}
+	c.hosts, c.netrc, c.loaded = hosts, entries, true
+	return nil
+}
+
+// HTTPClient returns the client requests to target's host use: the command-wide timeout and
+// TLS settings layered over the hosts file entry. Other clients talking to the same hosts,
+// such as the OCI registry client, use it to honour the download settings.
+func (c *Client) HTTPClient(target *url.URL) (*http.Client, error) {
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c.httpClient(target)
+}
+
+// httpClient returns the (cached) client for target's host, with TLS settings from the
+// flags layered over the hosts file entry.
+func (c *Client) httpClient(target *url.URL) (*http.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.clients[target.Host]; ok {
+		return client, nil
+	}
+
+	settings := tlsSettings{
+		caFile:   c.CAFile,
+		certFile: c.CertFile,
+		keyFile:  c.KeyFile,
+		insecure: c.InsecureSkipTLSVerify,
+	}
+	if host := c.hosts.lookup(target); host != nil {
+		settings.caFile = firstNonEmpty(settings.caFile, host.CAFile)
+		settings.certFile = firstNonEmpty(settings.certFile, host.CertFile)
+		settings.keyFile = firstNonEmpty(settings.keyFile, host.KeyFile)
+		settings.insecure = settings.insecure || host.InsecureSkipTLSVerify
+	}
+	tlsConfig, err := settings.config()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client := &http.Client{Transport: transport, Timeout: c.Timeout}
+	if c.clients == nil {
+		c.clients = map[string]*http.Client{}
+	}
+	c.clients[target.Host] = client
+	return client, nil
+}
+
+type tlsSettings struct {
+	caFile, certFile, keyFile string
+	insecure                  bool
+}
+
+func (s tlsSettings) config() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: s.insecure} //nolint:gosec // opt-in flag
+	if s.caFile != "" {
+		pem, err := os.ReadFile(s.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA file %s contains no PEM certificates", s.caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if s.certFile != "" || s.keyFile != "" {
+		if s.certFile == "" || s.keyFile == "" {
+			return nil, fmt.Errorf("client certificates need both a cert file and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// limitedReader fails with ErrTooLarge instead of silently truncating like io.LimitReader.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+// isTLSError reports certificate and handshake failures, which retrying cannot fix.
+func isTLSError(err error) bool {
+	var (
+		verifyErr   *tls.CertificateVerificationError
+		recordErr   tls.RecordHeaderError
+		unknownAuth x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		invalidErr  x509.CertificateInvalidError
+	)
+	return errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &unknownAuth) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
+
+// backoff doubles from 500ms per attempt, capped at maxBackoff.
+func backoff(attempt int) time.Duration {
+	wait := 500 * time.Millisecond << (attempt - 1)
+	if wait <= 0 || wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
+
+// parseRetryAfter understands the delay-seconds form of Retry-After, capped at maxBackoff.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	if wait := time.Duration(seconds) * time.Second; wait < maxBackoff {
+		return wait
+	}
+	return maxBackoff
+}
+
+// ParseSize parses a byte count with an optional binary suffix: 512, 64Ki, 100Mi, 1Gi
+// (K/M/G are accepted as aliases).
+func ParseSize(value string) (int64, error) {
+	s := strings.TrimSpace(value)
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		factor int64
+	}{{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSuffix(s, unit.suffix), unit.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (expected bytes or a Ki/Mi/Gi suffix)", value)
+	}
+	return n * multiplier, nil
+}
+
+func hostsFileHint(path string) string {
+	if path == "" {
+		return "a hosts file"
+	}
+	return path
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
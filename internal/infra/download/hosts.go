@@ -0,0 +1,80 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// HostsFile holds per-host download settings, keyed by host or host:port:
+//
+//	hosts:
+//	  charts.internal.example.com:
+//	    username: ci
+//	    passwordEnv: CHARTS_PASSWORD
+//	    caFile: /etc/ssl/internal-ca.pem
+type HostsFile struct {
+	Hosts map[string]*HostConfig `json:"hosts"`
+}
+
+// HostConfig configures credentials and TLS for one host. The *Env variants name
+// environment variables so secrets need not be stored in the file.
+type HostConfig struct {
+	Username              string `json:"username,omitempty"`
+	Password              string `json:"password,omitempty"`
+	PasswordEnv           string `json:"passwordEnv,omitempty"`
+	Token                 string `json:"token,omitempty"`
+	TokenEnv              string `json:"tokenEnv,omitempty"`
+	CAFile                string `json:"caFile,omitempty"`
+	CertFile              string `json:"certFile,omitempty"`
+	KeyFile               string `json:"keyFile,omitempty"`
+	InsecureSkipTLSVerify bool   `json:"insecureSkipTLSVerify,omitempty"`
+}
+
+// LoadHostsFile reads a hosts file. A missing file yields an empty configuration.
+func LoadHostsFile(path string) (*HostsFile, error) {
+	hosts := &HostsFile{}
+	if path == "" {
+		return hosts, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return hosts, nil
+		}
+		return nil, fmt.Errorf("read hosts file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, hosts); err != nil {
+		return nil, fmt.Errorf("parse hosts file %s: %w", path, err)
+	}
+	return hosts, nil
+}
+
+// lookup prefers an exact host:port entry over a bare hostname entry.
+func (h *HostsFile) lookup(target *url.URL) *HostConfig {
+	if h == nil {
+		return nil
+	}
+	if cfg, ok := h.Hosts[target.Host]; ok {
+		return cfg
+	}
+	return h.Hosts[target.Hostname()]
+}
+
+func (c *HostConfig) password() string {
+	if c.PasswordEnv != "" {
+		return os.Getenv(c.PasswordEnv)
+	}
+	return c.Password
+}
+
+func (c *HostConfig) token() string {
+	if c.TokenEnv != "" {
+		return os.Getenv(c.TokenEnv)
+	}
+	return c.Token
+}
@@ -0,0 +1,94 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// netrcEntry is one machine (or the default) from a .netrc file.
+type netrcEntry struct {
+	machine  string // empty for `default`
+	login    string
+	password string
+}
+
+// loadNetrc parses the machine/default/login/password tokens of a .netrc file; macdef
+// bodies are skipped. A missing file yields no entries.
+func loadNetrc(path string) ([]netrcEntry, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read netrc: %w", err)
+	}
+
+	var (
+		entries []netrcEntry
+		current *netrcEntry
+	)
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		for j := 0; j < len(fields); j++ {
+			token := fields[j]
+			if strings.HasPrefix(token, "#") {
+				break
+			}
+			next := func() string {
+				if j+1 < len(fields) {
+					j++
+					return fields[j]
+				}
+				return ""
+			}
+			switch token {
+			case "machine":
+				entries = append(entries, netrcEntry{machine: next()})
+				current = &entries[len(entries)-1]
+			case "default":
+				entries = append(entries, netrcEntry{})
+				current = &entries[len(entries)-1]
+			case "login":
+				if value := next(); current != nil {
+					current.login = value
+				}
+			case "password":
+				if value := next(); current != nil {
+					current.password = value
+				}
+			case "account":
+				next()
+			case "macdef":
+				// A macro runs until the next blank line.
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// lookupNetrc returns the entry for host, falling back to `default`.
+func lookupNetrc(entries []netrcEntry, host string) *netrcEntry {
+	var fallback *netrcEntry
+	for i := range entries {
+		switch entries[i].machine {
+		case host:
+			return &entries[i]
+		case "":
+			if fallback == nil {
+				fallback = &entries[i]
+			}
+		}
+	}
+	return fallback
+}
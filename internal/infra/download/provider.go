@@ -0,0 +1,6 @@
+package download
+
+import "github.com/google/wire"
+
+// ProviderSet exposes the download client for DI.
+var ProviderSet = wire.NewSet(New)
@@ -4,18 +4,29 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"io"
 	"os"
 	"os/exec"
+	"time"
 )
 
 // Command describes a process invocation.
 type Command struct {
-	Name string
-	Args []string
-	Dir  string
-	Env  []string
+	Name  string
+	Args  []string
+	Dir   string
+	Env   []string
+	Stdin io.Reader // optional
+	// Stdout, when set, receives the output as it is produced instead of it being returned.
+	Stdout io.Writer
+	// Stderr, when set, also receives the error output as it is produced; it is returned either way.
+	Stderr io.Writer
 }
 
+// waitDelay bounds how long a cancelled command may keep its output open, e.g. through
+// child processes that outlive it.
+const waitDelay = 2 * time.Second
+
 // Runner centralizes os/exec usage so we can stub it in tests.
 type Runner struct{}
 
@@ -37,11 +48,19 @@ func (r *Runner) Run(ctx context.Context, cmd Command) ([]byte, []byte, error) {
 	if len(cmd.Env) > 0 {
 		command.Env = append(os.Environ(), cmd.Env...)
 	}
+	command.Stdin = cmd.Stdin
+	command.WaitDelay = waitDelay
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 	command.Stdout = &stdout
 	command.Stderr = &stderr
+	if cmd.Stdout != nil {
+		command.Stdout = cmd.Stdout
+	}
+	if cmd.Stderr != nil {
+		command.Stderr = io.MultiWriter(&stderr, cmd.Stderr)
+	}
 
 	err := command.Run()
 	return stdout.Bytes(), stderr.Bytes(), err
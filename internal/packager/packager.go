@@ -2,43 +2,98 @@ package packager
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
+	"sigs.k8s.io/yaml"
+
 	"composepack/internal/core/chart"
+	"composepack/internal/util/fsutil"
 )
 
+// ChecksumSuffix names the sha256sum-compatible sidecar written next to an archive.
+const ChecksumSuffix = ".sha256"
+
 // Options controls packaging behavior.
 type Options struct {
 	ChartPath   string
 	Destination string
 	OutputName  string
 	Force       bool
+	// Reproducible produces byte-identical archives for identical chart sources: entries
+	// are sorted, timestamps come from ModTime (or SOURCE_DATE_EPOCH, or the Unix epoch),
+	// ownership is dropped and modes are normalized to 0644/0755.
+	Reproducible bool
+	ModTime      time.Time
+	// Checksum also writes `<archive>.sha256` in sha256sum format.
+	Checksum bool
+	// Version and AppVersion, when set, replace those fields of Chart.yaml inside the
+	// archive; the chart sources are left untouched.
+	Version    string
+	AppVersion string
+	// ExtraFiles adds files that are not part of the chart sources, keyed by their
+	// slash-separated name in the archive (e.g. bundled images).
+	ExtraFiles map[string]string
+}
+
+// Result describes a packaged chart.
+type Result struct {
+	Path         string
+	Digest       string // sha256:<hex> of the archive
+	ChecksumPath string // empty unless Options.Checksum was set
+}
+
+// entry is a file or directory to archive, relative to the chart root.
+type entry struct {
+	path string
+	name string // slash-separated name inside the archive
+	info fs.FileInfo
+	data []byte // replaces the file contents when set
 }
 
 // PackageChart produces a .cpack.tgz archive containing the chart source.
-func PackageChart(ctx context.Context, loader chart.Loader, opts Options) (string, error) {
+func PackageChart(ctx context.Context, loader chart.Loader, opts Options) (*Result, error) {
 	if loader == nil {
-		return nilPathErr("loader is required")
+		return nil, errors.New("loader is required")
 	}
 	if opts.ChartPath == "" {
-		return nilPathErr("chart path is required")
+		return nil, errors.New("chart path is required")
 	}
 	chartPath, err := filepath.Abs(opts.ChartPath)
 	if err != nil {
-		return "", fmt.Errorf("resolve chart path: %w", err)
+		return nil, fmt.Errorf("resolve chart path: %w", err)
 	}
 
 	ch, err := loader.Load(ctx, chartPath)
 	if err != nil {
-		return "", fmt.Errorf("load chart: %w", err)
+		return nil, fmt.Errorf("load chart: %w", err)
+	}
+	metadata, err := OverrideMetadata(filepath.Join(chartPath, chart.MetadataFile), opts.Version, opts.AppVersion)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Version != "" {
+		ch.Metadata.Version = opts.Version
+	}
+
+	modTime := time.Now()
+	if opts.Reproducible {
+		if modTime, err = sourceDate(opts.ModTime); err != nil {
+			return nil, err
+		}
 	}
 
 	destDir := opts.Destination
@@ -46,7 +101,7 @@ func PackageChart(ctx context.Context, loader chart.Loader, opts Options) (strin
 		destDir = "."
 	}
 	if err := os.MkdirAll(destDir, 0o755); err != nil {
-		return "", fmt.Errorf("ensure destination: %w", err)
+		return nil, fmt.Errorf("ensure destination: %w", err)
 	}
 
 	filename := opts.OutputName
@@ -57,25 +112,98 @@ func PackageChart(ctx context.Context, loader chart.Loader, opts Options) (strin
 	outputPath := filepath.Join(destDir, filename)
 	if !opts.Force {
 		if _, err := os.Stat(outputPath); err == nil {
-			return "", fmt.Errorf("output file %s already exists (use --force to overwrite)", outputPath)
+			return nil, fmt.Errorf("output file %s already exists (use --force to overwrite)", outputPath)
+		}
+	}
+
+	entries, err := collect(chartPath, opts.Reproducible)
+	if err != nil {
+		return nil, fmt.Errorf("archive chart: %w", err)
+	}
+	if entries, err = addExtraFiles(entries, opts.ExtraFiles, opts.Reproducible); err != nil {
+		return nil, fmt.Errorf("archive chart: %w", err)
+	}
+	if metadata != nil {
+		for i := range entries {
+			if entries[i].name == chart.MetadataFile {
+				entries[i].data = metadata
+			}
 		}
 	}
 
 	file, err := os.Create(outputPath)
 	if err != nil {
-		return "", fmt.Errorf("create output file: %w", err)
+		return nil, fmt.Errorf("create output file: %w", err)
 	}
 	defer file.Close()
 
 	gz := gzip.NewWriter(file)
-	gz.Name = filename
-	gz.ModTime = time.Now()
+	if !opts.Reproducible {
+		// The gzip header would otherwise make the digest depend on the output name.
+		gz.Name = filename
+		gz.ModTime = modTime
+	}
 	defer gz.Close()
 
 	tw := tar.NewWriter(gz)
 	defer tw.Close()
 
-	if err := filepath.WalkDir(chartPath, func(path string, d os.DirEntry, err error) error {
+	for _, e := range entries {
+		if err := writeEntry(tw, e, opts.Reproducible, modTime); err != nil {
+			return nil, fmt.Errorf("archive chart: %s: %w", e.name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Path: outputPath}
+	if result.Digest, err = chart.FileDigest(outputPath); err != nil {
+		return nil, err
+	}
+	if opts.Checksum {
+		result.ChecksumPath = outputPath + ChecksumSuffix
+		line := fmt.Sprintf("%s  %s\n", strings.TrimPrefix(result.Digest, "sha256:"), filename)
+		if err := fsutil.WriteFileAtomic(ctx, result.ChecksumPath, []byte(line), 0o644); err != nil {
+			return nil, fmt.Errorf("write checksum: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// List returns the files (slash-separated, relative to the chart root) that packaging
+// chartPath would include, sorted.
+func List(chartPath string) ([]string, error) {
+	entries, err := collect(chartPath, true)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.info.IsDir() {
+			files = append(files, e.name)
+		}
+	}
+	return files, nil
+}
+
+// collect lists the files and directories to archive, honoring .cpackignore, sorted by
+// archive name when reproducible (WalkDir order depends on the platform's notion of
+// lexical order).
+func collect(chartPath string, reproducible bool) ([]entry, error) {
+	rules, err := chart.LoadIgnoreRules(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	var entries []entry
+	err = filepath.WalkDir(chartPath, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -86,52 +214,175 @@ func PackageChart(ctx context.Context, loader chart.Loader, opts Options) (strin
 		if rel == "." {
 			return nil
 		}
-		if shouldSkip(rel) {
+		if shouldSkip(rel) || rules.Ignored(filepath.ToSlash(rel), d.IsDir()) {
 			if d.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-
 		info, err := d.Info()
 		if err != nil {
 			return err
 		}
-		header, err := tar.FileInfoHeader(info, "")
-		if err != nil {
-			return err
-		}
-		header.Name = filepath.ToSlash(rel)
-		if err := tw.WriteHeader(header); err != nil {
-			return err
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			// Extraction refuses links and special files, so never ship them.
+			return fmt.Errorf("%s: only regular files and directories can be packaged", filepath.ToSlash(rel))
 		}
-		if d.IsDir() {
-			return nil
+		entries = append(entries, entry{path: path, name: filepath.ToSlash(rel), info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reproducible {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	}
+	return entries, nil
+}
+
+// addExtraFiles appends extra files after the chart sources, refusing to shadow them.
+func addExtraFiles(entries []entry, extra map[string]string, reproducible bool) ([]entry, error) {
+	if len(extra) == 0 {
+		return entries, nil
+	}
+	existing := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		existing[e.name] = true
+	}
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if existing[name] {
+			return nil, fmt.Errorf("%s: the chart already contains this file", name)
 		}
-		f, err := os.Open(path)
+		info, err := os.Stat(extra[name])
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if _, err := io.Copy(tw, f); err != nil {
-			f.Close()
-			return err
+		entries = append(entries, entry{path: extra[name], name: name, info: info})
+	}
+	if reproducible {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	}
+	return entries, nil
+}
+
+func writeEntry(tw *tar.Writer, e entry, reproducible bool, modTime time.Time) error {
+	header, err := tar.FileInfoHeader(e.info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = e.name
+	if reproducible {
+		header = normalizedHeader(e, modTime)
+	}
+	if e.data != nil {
+		header.Size = int64(len(e.data))
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if e.info.IsDir() {
+		return nil
+	}
+	if e.data != nil {
+		_, err := tw.Write(e.data)
+		return err
+	}
+	f, err := os.Open(e.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// normalizedHeader keeps only what a chart needs: name, type, size and the executable bit.
+func normalizedHeader(e entry, modTime time.Time) *tar.Header {
+	header := &tar.Header{
+		Name:     e.name,
+		Typeflag: tar.TypeReg,
+		Mode:     0o644,
+		Size:     e.info.Size(),
+		ModTime:  modTime,
+	}
+	if e.info.IsDir() {
+		header.Name += "/"
+		header.Typeflag, header.Mode, header.Size = tar.TypeDir, 0o755, 0
+	} else if e.info.Mode()&0o111 != 0 {
+		header.Mode = 0o755
+	}
+	return header
+}
+
+// OverrideMetadata returns the Chart.yaml at path with version and appVersion replaced, or
+// nil when neither is set. Only the matching top-level lines change, so comments and key
+// order survive.
+func OverrideMetadata(path, version, appVersion string) ([]byte, error) {
+	if version == "" && appVersion == "" {
+		return nil, nil
+	}
+	if version != "" {
+		if _, err := semver.StrictNewVersion(version); err != nil {
+			return nil, fmt.Errorf("--version %q is not a semantic version: %w", version, err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", chart.MetadataFile, err)
+	}
+	if version != "" {
+		if data, err = setTopLevelField(data, "version", version); err != nil {
+			return nil, err
+		}
+	}
+	if appVersion != "" {
+		if data, err = setTopLevelField(data, "appVersion", appVersion); err != nil {
+			return nil, err
 		}
-		return f.Close()
-	}); err != nil {
-		return "", fmt.Errorf("archive chart: %w", err)
 	}
+	if _, err := chart.ParseMetadata(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
 
-	if err := tw.Close(); err != nil {
-		return "", err
+// setTopLevelField replaces `key: ...` at the start of a line, appending it when absent.
+func setTopLevelField(data []byte, key, value string) ([]byte, error) {
+	scalar, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
 	}
-	if err := gz.Close(); err != nil {
-		return "", err
+	line := key + ": " + strings.TrimSpace(string(scalar))
+	pattern := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + `:.*$`)
+	if pattern.Match(data) {
+		return pattern.ReplaceAllLiteral(data, []byte(line)), nil
 	}
-	if err := file.Close(); err != nil {
-		return "", err
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
 	}
+	return append(data, line+"\n"...), nil
+}
 
-	return outputPath, nil
+// sourceDate picks the timestamp of reproducible archives: an explicit time, then
+// SOURCE_DATE_EPOCH (https://reproducible-builds.org/specs/source-date-epoch/), then the
+// Unix epoch.
+func sourceDate(explicit time.Time) (time.Time, error) {
+	if !explicit.IsZero() {
+		return explicit.UTC().Truncate(time.Second), nil
+	}
+	if value := os.Getenv("SOURCE_DATE_EPOCH"); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seconds < 0 {
+			return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: expected a non-negative Unix timestamp", value)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Unix(0, 0).UTC(), nil
 }
 
 func shouldSkip(rel string) bool {
@@ -143,7 +394,3 @@ func shouldSkip(rel string) bool {
 	}
 	return false
 }
-
-func nilPathErr(msg string) (string, error) {
-	return "", errors.New(msg)
-}
@@ -52,6 +52,7 @@ func CreateChart(opts Options) error {
 		filepath.Join(path, "templates", "compose", "00-app.tpl.yaml"):         composeTemplate,
 		filepath.Join(path, "templates", "files", "config", "message.txt.tpl"): fileTemplate,
 		filepath.Join(path, "templates", "helpers", "_helpers.tpl"):            helperTemplate,
+		filepath.Join(path, "templates", "NOTES.txt"):                          notesTemplate,
 		filepath.Join(path, "README.md"):                                       scaffoldReadme(opts.Name),
 	}
 
@@ -129,6 +130,14 @@ const helperTemplate = `{{- define "example.fullname" -}}
 {{- end -}}
 `
 
+const notesTemplate = `{{ .Chart.Name }} {{ .Chart.Version }} is installed as release "{{ .Release.Name }}".
+
+Open http://localhost:8080 to see the welcome page.
+
+  composepack ps {{ .Release.Name }}
+  composepack logs {{ .Release.Name }} --follow
+`
+
 func scaffoldReadme(name string) string {
 	return fmt.Sprintf(`# %s
 
@@ -139,6 +148,7 @@ This directory was generated by composepack init. Key files:
 - templates/compose/*.tpl.yaml: templated Compose fragments rendered into the final docker-compose.yaml.
 - templates/files/**/*.tpl: templated runtime files copied under .cpack-releases/<release>/files.
 - templates/helpers/_helpers.tpl: reusable snippets for {{ include }}.
+- templates/NOTES.txt: printed after install/up; shown again by composepack notes <release>.
 
 Edit the templates/values to match your app, then run:
 
@@ -62,6 +62,12 @@ func (l *FileSystemLoader) ReadFile(path string) ([]byte, error) {
 
 // WalkFiles walks the directory tree rooted at dir, invoking visit for each file.
 func (l *FileSystemLoader) WalkFiles(ctx context.Context, dir string, visit func(rel string, data []byte) error) error {
+	return l.WalkFilesExcept(ctx, dir, nil, visit)
+}
+
+// WalkFilesExcept is WalkFiles without the files and directories for which skip (when
+// non-nil) returns true; skipped files are never read.
+func (l *FileSystemLoader) WalkFilesExcept(ctx context.Context, dir string, skip func(rel string, isDir bool) bool, visit func(rel string, data []byte) error) error {
 	info, err := os.Stat(dir)
 	if errors.Is(err, os.ErrNotExist) {
 		return nil
@@ -77,9 +83,6 @@ func (l *FileSystemLoader) WalkFiles(ctx context.Context, dir string, visit func
 		if err != nil {
 			return err
 		}
-		if d.IsDir() {
-			return nil
-		}
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
@@ -88,6 +91,15 @@ func (l *FileSystemLoader) WalkFiles(ctx context.Context, dir string, visit func
 		if relErr != nil {
 			return relErr
 		}
+		if skip != nil && rel != "." && skip(filepath.ToSlash(rel), d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
 
 		data, readErr := os.ReadFile(path)
 		if readErr != nil {
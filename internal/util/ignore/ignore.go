@@ -0,0 +1,159 @@
+// Package ignore implements gitignore-style path rules, as used by `.cpackignore`.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Rules is an ordered list of patterns; the last pattern matching a path decides.
+type Rules struct {
+	patterns []pattern
+}
+
+type pattern struct {
+	segments []string // slash-separated glob segments; "**" matches any number of segments
+	negate   bool     // `!pattern` re-includes a path
+	dirOnly  bool     // `pattern/` only matches directories
+}
+
+// Load reads rules from a file. A missing file yields empty rules.
+func Load(file string) (*Rules, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &Rules{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	rules, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return rules, nil
+}
+
+// Parse reads gitignore syntax: blank lines and `#` comments are skipped, `!` negates,
+// a trailing `/` matches directories only, and a pattern containing a `/` (other than a
+// trailing one) is anchored to the root while others match at any depth. `*`, `?`,
+// `[...]` and `**` behave as in git; `\` escapes a leading `#` or `!`.
+func Parse(r io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := trimTrailingSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p pattern
+		if strings.HasPrefix(line, "!") {
+			p.negate, line = true, line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly, line = true, strings.TrimRight(line, "/")
+		}
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		p.segments = strings.Split(line, "/")
+		for _, segment := range p.segments {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("line %d: invalid pattern %q: %w", lineNo, scanner.Text(), err)
+			}
+		}
+		if !anchored {
+			p.segments = append([]string{"**"}, p.segments...)
+		}
+		rules.patterns = append(rules.patterns, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Empty reports whether there are no rules.
+func (r *Rules) Empty() bool {
+	return r == nil || len(r.patterns) == 0
+}
+
+// Ignored reports whether the slash-separated path rel (relative to the root the rules
+// apply to) is excluded. As in git, nothing below an excluded directory can be re-included.
+func (r *Rules) Ignored(rel string, isDir bool) bool {
+	if r.Empty() {
+		return false
+	}
+	segments := strings.Split(strings.Trim(path.Clean(rel), "/"), "/")
+	for i := 1; i < len(segments); i++ {
+		if r.match(segments[:i], true) {
+			return true
+		}
+	}
+	return r.match(segments, isDir)
+}
+
+// match applies the rules to a single path, ignoring its parents.
+func (r *Rules) match(segments []string, isDir bool) bool {
+	ignored := false
+	for _, p := range r.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(p.segments, segments) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			// A trailing `/**` matches everything inside, but not the directory itself.
+			return len(segments) > 0
+		}
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// trimTrailingSpace drops unescaped trailing spaces.
+func trimTrailingSpace(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-2] + " "
+	}
+	return line
+}